@@ -0,0 +1,177 @@
+// Command rlpdump prints RLP encoded data as an annotated tree.
+//
+// Usage:
+//
+//	rlpdump [-strict] [-json [-text]] [file]
+//	rlpdump [-strict] [-json [-text]] -hex c88363617483646f67
+//	rlpdump -reverse '[["dog","cat"],"0x01"]'
+//
+// Input is read from the file or, without one, from stdin. Input consisting
+// of hex digits only (optionally prefixed by 0x) is decoded from hex first.
+//
+// With -reverse the argument (or stdin) is a JSON literal which is encoded to
+// RLP and printed as hex. Strings starting with 0x are taken as hex encoded
+// bytes, other strings as text, numbers as unsigned integers and arrays as
+// lists. See Value.JSON and Value.UnmarshalJSON for the mapping used by -json
+// and -reverse.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ethereum/ethutil-go"
+)
+
+var (
+	hexMode     = flag.String("hex", "", "dump the given hex string")
+	strictMode  = flag.Bool("strict", false, "reject data which isn't canonically encoded")
+	jsonMode    = flag.Bool("json", false, "print the decoded value as JSON")
+	textMode    = flag.Bool("text", false, "print printable strings as text in JSON output")
+	reverseMode = flag.Bool("reverse", false, "encode a JSON literal and print it as hex")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: rlpdump [-strict] [-json [-text]] [-hex <data> | file]")
+		fmt.Fprintln(os.Stderr, "       rlpdump -reverse [literal]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *reverseMode {
+		if err := reverse(); err != nil {
+			die(err)
+		}
+
+		return
+	}
+
+	data, err := readInput()
+	if err != nil {
+		die(err)
+	}
+
+	if *strictMode {
+		if err := ethutil.IsCanonical(data); err != nil {
+			fmt.Print(ethutil.RlpDump(data))
+			die(err)
+		}
+	}
+
+	if *jsonMode {
+		val, err := ethutil.DecodeValue(data)
+		if err != nil {
+			die(err)
+		}
+
+		out, err := val.JSON(*textMode)
+		if err != nil {
+			die(err)
+		}
+
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, out, "", "  "); err != nil {
+			die(err)
+		}
+		fmt.Println(buf.String())
+
+		return
+	}
+
+	fmt.Print(ethutil.RlpDump(data))
+	if _, err := ethutil.DecodeBytes(data); err != nil {
+		die(err)
+	}
+}
+
+func die(err error) {
+	fmt.Fprintln(os.Stderr, "rlpdump:", err)
+	os.Exit(1)
+}
+
+// Reads the raw RLP data from -hex, the file argument or stdin
+func readInput() ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch {
+	case *hexMode != "":
+		return decodeHex(*hexMode)
+	case flag.NArg() > 1:
+		return nil, errors.New("too many arguments")
+	case flag.NArg() == 1:
+		data, err = ioutil.ReadFile(flag.Arg(0))
+	default:
+		data, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if isHex(data) {
+		return decodeHex(string(data))
+	}
+
+	return data, nil
+}
+
+// Whether data looks like hex text rather than binary RLP
+func isHex(data []byte) bool {
+	str := strings.TrimSpace(string(data))
+	str = strings.TrimPrefix(str, "0x")
+	if len(str) == 0 || len(str)%2 != 0 {
+		return false
+	}
+
+	for _, c := range str {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decodeHex(str string) ([]byte, error) {
+	str = strings.TrimPrefix(strings.TrimSpace(str), "0x")
+
+	return hex.DecodeString(str)
+}
+
+// Encodes the JSON literal given as argument or on stdin
+func reverse() error {
+	var (
+		input []byte
+		err   error
+	)
+
+	switch flag.NArg() {
+	case 0:
+		input, err = ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+	case 1:
+		input = []byte(flag.Arg(0))
+	default:
+		return errors.New("too many arguments")
+	}
+
+	var val ethutil.Value
+	if err := json.Unmarshal(input, &val); err != nil {
+		return err
+	}
+	fmt.Printf("%x\n", val.Encode())
+
+	return nil
+}
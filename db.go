@@ -1,5 +1,10 @@
 package ethutil
 
+import (
+	"errors"
+	"fmt"
+)
+
 // Database interface
 type Database interface {
 	Put(key []byte, value []byte)
@@ -8,3 +13,37 @@ type Database interface {
 	Close()
 	Print()
 }
+
+// In memory Database used to work on partial tries, e.g. ones rebuilt from
+// proofs
+type memDatabase struct {
+	db map[string][]byte
+}
+
+func newMemDatabase() *memDatabase {
+	return &memDatabase{db: make(map[string][]byte)}
+}
+
+func (db *memDatabase) Put(key []byte, value []byte) {
+	db.db[string(key)] = value
+}
+
+func (db *memDatabase) Get(key []byte) ([]byte, error) {
+	if value, ok := db.db[string(key)]; ok {
+		return value, nil
+	}
+
+	return nil, errors.New("not found")
+}
+
+func (db *memDatabase) LastKnownTD() []byte {
+	return nil
+}
+
+func (db *memDatabase) Close() {}
+
+func (db *memDatabase) Print() {
+	for key, value := range db.db {
+		fmt.Printf("%x: %x\n", key, value)
+	}
+}
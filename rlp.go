@@ -1,22 +1,25 @@
 package ethutil
 
 import (
-	"bytes"
-	"encoding/binary"
+	"errors"
 	"fmt"
-	_ "io"
-	"log"
+	"io"
 	_ "math"
 	"math/big"
 	"reflect"
 )
 
 ///////////////////////////////////////
+// Types implementing EthEncoder control their own RLP encoding. EncodeRLP must
+// write exactly one RLP item.
 type EthEncoder interface {
-	EncodeData(rlpData interface{}) []byte
+	EncodeRLP(io.Writer) error
 }
+
+// Types implementing EthDecoder control their own RLP decoding. DecodeRLP must
+// read exactly one item from the stream.
 type EthDecoder interface {
-	Get(idx int) *RlpValue
+	DecodeRLP(*Stream) error
 }
 
 //////////////////////////////////////
@@ -30,13 +33,15 @@ func NewRlpEncoder() *RlpEncoder {
 
 	return encoder
 }
+
+// Like Encode it returns nil if rlpData can't be encoded
 func (coder *RlpEncoder) EncodeData(rlpData interface{}) []byte {
 	return Encode(rlpData)
 }
 
-// Data rlpValueutes are returned by the rlp decoder. The data rlpValueutes represents
-// one item within the rlp data structure. It's responsible for all the casting
-// It always returns something rlpValueid
+// RlpValue is the predecessor of Value and is kept for compatibility. It
+// holds the same data and delegates to Value, so both behave the same. New
+// code should use Value.
 type RlpValue struct {
 	Value interface{}
 	kind  reflect.Value
@@ -54,139 +59,91 @@ func NewRlpValue(rlpValue interface{}) *RlpValue {
 	return &RlpValue{Value: rlpValue}
 }
 
+// The Value this RlpValue delegates to
+func (rlpValue *RlpValue) AsValue() *Value {
+	return NewValue(rlpValue.Value)
+}
+
 func (rlpValue *RlpValue) Type() reflect.Kind {
-	return reflect.TypeOf(rlpValue.Value).Kind()
+	return rlpValue.AsValue().Type()
 }
 
 func (rlpValue *RlpValue) IsNil() bool {
-	return rlpValue.Value == nil
+	return rlpValue.AsValue().IsNil()
 }
 
+// Returns the amount of items if the value is a list. Unlike Value.Len it
+// returns 0 for strings.
 func (rlpValue *RlpValue) Length() int {
-	//return rlpValue.kind.Len()
-	if data, ok := rlpValue.Value.([]interface{}); ok {
-		return len(data)
-	}
-
-	return 0
+	return len(rlpValue.AsSlice())
 }
 
 func (rlpValue *RlpValue) AsRaw() interface{} {
-	return rlpValue.Value
+	return rlpValue.AsValue().Raw()
 }
 
 func (rlpValue *RlpValue) AsUint() uint64 {
-	if Value, ok := rlpValue.Value.(uint8); ok {
-		return uint64(Value)
-	} else if Value, ok := rlpValue.Value.(uint16); ok {
-		return uint64(Value)
-	} else if Value, ok := rlpValue.Value.(uint32); ok {
-		return uint64(Value)
-	} else if Value, ok := rlpValue.Value.(uint64); ok {
-		return Value
-	}
-
-	return 0
+	return rlpValue.AsValue().Uint()
 }
 
 func (rlpValue *RlpValue) AsByte() byte {
-	if Value, ok := rlpValue.Value.(byte); ok {
-		return Value
-	}
-
-	return 0x0
+	return rlpValue.AsValue().Byte()
 }
 
 func (rlpValue *RlpValue) AsBigInt() *big.Int {
-	if a, ok := rlpValue.Value.([]byte); ok {
-		b := new(big.Int)
-		b.SetBytes(a)
-		return b
-	}
-
-	return big.NewInt(0)
+	return rlpValue.AsValue().BigInt()
 }
 
 func (rlpValue *RlpValue) AsString() string {
-	if a, ok := rlpValue.Value.([]byte); ok {
-		return string(a)
-	} else if a, ok := rlpValue.Value.(string); ok {
-		return a
-	} else {
-		//panic(fmt.Sprintf("not string %T: %v", rlpValue.Value, rlpValue.Value))
-	}
-
-	return ""
+	return rlpValue.AsValue().Str()
 }
 
 func (rlpValue *RlpValue) AsBytes() []byte {
-	if a, ok := rlpValue.Value.([]byte); ok {
-		return a
-	}
-
-	return make([]byte, 0)
+	return rlpValue.AsValue().Bytes()
 }
 
 func (rlpValue *RlpValue) AsSlice() []interface{} {
-	if d, ok := rlpValue.Value.([]interface{}); ok {
-		return d
-	}
-
-	return []interface{}{}
+	return rlpValue.AsValue().Slice()
 }
 
 func (rlpValue *RlpValue) AsSliceFrom(from int) *RlpValue {
-	slice := rlpValue.AsSlice()
-
-	return NewRlpValue(slice[from:])
+	return NewRlpValue(rlpValue.AsValue().SliceFrom(from).Val)
 }
 
 func (rlpValue *RlpValue) AsSliceTo(to int) *RlpValue {
-	slice := rlpValue.AsSlice()
-
-	return NewRlpValue(slice[:to])
+	return NewRlpValue(rlpValue.AsValue().SliceTo(to).Val)
 }
 
 func (rlpValue *RlpValue) AsSliceFromTo(from, to int) *RlpValue {
-	slice := rlpValue.AsSlice()
-
-	return NewRlpValue(slice[from:to])
+	return NewRlpValue(rlpValue.AsValue().SliceFromTo(from, to).Val)
 }
 
-// Threat the rlpValueute as a slice
+// Threat the value as a slice
 func (rlpValue *RlpValue) Get(idx int) *RlpValue {
-	if d, ok := rlpValue.Value.([]interface{}); ok {
-		// Guard for oob
-		if len(d) <= idx {
-			return NewRlpValue(nil)
-		}
-
-		if idx < 0 {
-			panic("negative idx for Rlp Get")
-		}
-
-		return NewRlpValue(d[idx])
-	}
-
-	// If this wasn't a slice you probably shouldn't be using this function
-	return NewRlpValue(nil)
+	return NewRlpValue(rlpValue.AsValue().Get(idx).Val)
 }
 
 func (rlpValue *RlpValue) Cmp(o *RlpValue) bool {
-	return reflect.DeepEqual(rlpValue.Value, o.Value)
+	return rlpValue.AsValue().Cmp(o.AsValue())
 }
 
+// Like Value.Encode it returns nil if the value can't be encoded
 func (rlpValue *RlpValue) Encode() []byte {
-	return Encode(rlpValue.Value)
+	return rlpValue.AsValue().Encode()
 }
 
 func NewRlpValueFromBytes(rlpData []byte) *RlpValue {
-	if len(rlpData) != 0 {
-		data, _ := Decode(rlpData, 0)
-		return NewRlpValue(data)
+	return NewRlpValue(NewValueFromBytes(rlpData).Val)
+}
+
+// Like NewRlpValueFromBytes but returns an error for malformed data
+func DecodeRlpValue(rlpData []byte) (*RlpValue, error) {
+	val, err := DecodeValue(rlpData)
+	if err != nil {
+		return nil, err
 	}
 
-	return NewRlpValue(nil)
+	return NewRlpValue(val.Val), nil
 }
 
 // RlpValue value setters
@@ -223,106 +180,217 @@ const (
 	RlpEmptyStr  = 0x40
 )
 
-/*
-func ReadUntill(reader io.Reader, len int) (char []byte) {
-	char = make([]byte, len)
-	reader.Read(char)
+// Decodes the item at pos and returns it together with the position of the
+// item following it. Decode applies DefaultDecodeOptions and panics with an
+// *RlpError if data is malformed, use DecodeBytes for untrusted data.
+func Decode(data []byte, pos uint64) (interface{}, uint64) {
+	val, next, err := decodeChecked(data, pos, &DefaultDecodeOptions, 0)
+	if err != nil {
+		panic(err)
+	}
 
-	return
+	return val, next
 }
 
-func DecodeWithReader(reader io.Reader) interface{} {
-	var slice []interface{}
+// Errors returned by the checked decoder. They're always wrapped in a
+// *RlpError which holds the offset at which decoding failed.
+var (
+	ErrRlpTruncated = errors.New("unexpected end of input")
+	ErrRlpOversized = errors.New("value size exceeds available input")
+	ErrRlpTrailing  = errors.New("trailing bytes after value")
+
+	// Only returned in strict mode, see DecodeStrict
+	ErrRlpCanonByte = errors.New("non-canonical single byte string")
+	ErrRlpCanonSize = errors.New("non-canonical long form size")
+	ErrRlpCanonZero = errors.New("non-canonical size with leading zero bytes")
+
+	// Returned when exceeding one of the DecodeOptions limits
+	ErrRlpMaxDepth     = errors.New("maximum nesting depth exceeded")
+	ErrRlpMaxListItems = errors.New("maximum amount of list items exceeded")
+	ErrRlpMaxTotalSize = errors.New("maximum input size exceeded")
+)
 
-	// Read the next byte
-	char := GetChar(reader)
-	switch {
-	case char <= 0x7c:
-		return data[pos], pos + 1
+// Limits applied while decoding. A limit of 0 means no limit.
+type DecodeOptions struct {
+	// Maximum nesting of lists; a list which isn't part of another list has a
+	// depth of 1
+	MaxDepth int
+	// Maximum amount of items in a single list
+	MaxListItems int
+	// Maximum size of the data being decoded
+	MaxTotalSize uint64
 
-	case char <= 0xb7:
-		b := uint64(data[pos]) - 0x80
+	// Reject data which isn't canonically encoded, see DecodeStrict
+	Strict bool
+}
 
-		return data[pos+1 : pos+1+b], pos + 1 + b
-	}
+// The options used by Decode, DecodeBytes, NewValueFromBytes and the trie
+var DefaultDecodeOptions = DecodeOptions{
+	MaxDepth: 128,
+}
 
-	return slice
+type RlpError struct {
+	Err error
+	Pos uint64
 }
-*/
 
-// TODO Use a bytes.Buffer instead of a raw byte slice.
-// Cleaner code, and use draining instead of seeking the next bytes to read
-func Decode(data []byte, pos uint64) (interface{}, uint64) {
-	if pos > uint64(len(data)-1) {
-		log.Println(data)
-		log.Panicf("index out of range %d for data %q, l = %d", pos, data, len(data))
+func (err *RlpError) Error() string {
+	return fmt.Sprintf("rlp: %v at offset %d", err.Err, err.Pos)
+}
+
+// Reads the header of the item at pos and returns whether it's a list and the
+// position and size of its payload. A single byte is its own payload. In strict
+// mode headers which aren't the shortest possible encoding are rejected.
+func readHeader(data []byte, pos uint64, strict bool) (list bool, start, size uint64, err error) {
+	if pos >= uint64(len(data)) {
+		return false, 0, 0, &RlpError{ErrRlpTruncated, pos}
 	}
 
-	var slice []interface{}
-	char := int(data[pos])
+	char := data[pos]
 	switch {
 	case char <= 0x7f:
-		return data[pos], pos + 1
-
+		return false, pos, 1, nil
 	case char <= 0xb7:
-		b := uint64(data[pos]) - 0x80
+		start, size = pos+1, uint64(char-0x80)
+	case char <= 0xbf:
+		start, size, err = readLongSize(data, pos, uint64(char-0xb7), strict)
+	case char <= 0xf7:
+		list, start, size = true, pos+1, uint64(char-0xc0)
+	default:
+		list = true
+		start, size, err = readLongSize(data, pos, uint64(char-0xf7), strict)
+	}
 
-		return data[pos+1 : pos+1+b], pos + 1 + b
+	if err == nil && size > uint64(len(data))-start {
+		err = &RlpError{ErrRlpOversized, pos}
+	}
 
-	case char <= 0xbf:
-		b := uint64(data[pos]) - 0xb8
+	// A single byte below 0x80 is its own encoding
+	if err == nil && strict && char == 0x81 && data[start] <= 0x7f {
+		err = &RlpError{ErrRlpCanonByte, pos}
+	}
 
-		buf := bytes.NewBuffer(data[pos+1 : pos+1+b])
-		var b2 uint64
-		binary.Read(buf, binary.BigEndian, &b2)
+	return
+}
 
-		//b2 := uint64(FromBin(data[pos+1 : pos+1+b]))
+// Reads the big endian length of l bytes following the header at pos
+func readLongSize(data []byte, pos, l uint64, strict bool) (start, size uint64, err error) {
+	start = pos + 1 + l
+	if start > uint64(len(data)) {
+		return 0, 0, &RlpError{ErrRlpTruncated, pos}
+	}
 
-		return data[pos+1+b : pos+1+b+b2], pos + 1 + b + b2
+	if strict && data[pos+1] == 0 {
+		return 0, 0, &RlpError{ErrRlpCanonZero, pos}
+	}
 
-	case char <= 0xf7:
-		b := uint64(data[pos]) - 0xc0
-		prevPos := pos
-		pos++
-		for i := uint64(0); i < b; {
-			var obj interface{}
-
-			// Get the next item in the data list and append it
-			obj, prevPos = Decode(data, pos)
-			slice = append(slice, obj)
-
-			// Increment i by the amount bytes read in the previous
-			// read
-			i += (prevPos - pos)
-			pos = prevPos
+	for _, b := range data[pos+1 : start] {
+		size = size<<8 | uint64(b)
+	}
+
+	if strict && size < 56 {
+		return 0, 0, &RlpError{ErrRlpCanonSize, pos}
+	}
+
+	return
+}
+
+// Decodes the item at pos without ever reading past the end of data. The
+// returned values have the same shape as those returned by Decode. depth is
+// the amount of lists enclosing the item.
+func decodeChecked(data []byte, pos uint64, opts *DecodeOptions, depth int) (interface{}, uint64, error) {
+	if depth == 0 && opts.MaxTotalSize > 0 && uint64(len(data)) > opts.MaxTotalSize {
+		return nil, pos, &RlpError{ErrRlpMaxTotalSize, opts.MaxTotalSize}
+	}
+
+	list, start, size, err := readHeader(data, pos, opts.Strict)
+	if err != nil {
+		return nil, pos, err
+	}
+
+	end := start + size
+	if !list {
+		if start == pos {
+			return data[pos], end, nil
+		}
+
+		return data[start:end], end, nil
+	}
+
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+		return nil, pos, &RlpError{ErrRlpMaxDepth, pos}
+	}
+
+	// Items may not run past the end of their enclosing list
+	var slice []interface{}
+	for pos = start; pos < end; {
+		if opts.MaxListItems > 0 && len(slice) >= opts.MaxListItems {
+			return nil, pos, &RlpError{ErrRlpMaxListItems, pos}
 		}
-		return slice, pos
 
-	case char <= 0xff:
-		l := uint64(data[pos]) - 0xf7
-		b := BigD(data[pos+1 : pos+1+l]).Uint64()
-		//fmt.Println("b", b)
-		//b := uint64(data[pos+l])
+		var obj interface{}
+		obj, pos, err = decodeChecked(data[:end], pos, opts, depth+1)
+		if err != nil {
+			return nil, pos, err
+		}
 
-		pos = pos + l + 1
+		slice = append(slice, obj)
+	}
 
-		prevPos := b
-		for i := uint64(0); i < uint64(b); {
-			var obj interface{}
+	return slice, end, nil
+}
 
-			obj, prevPos = Decode(data, pos)
-			slice = append(slice, obj)
+// Decodes a single RLP item spanning the whole of data while applying the
+// options' limits
+func (opts *DecodeOptions) DecodeBytes(data []byte) (interface{}, error) {
+	val, pos, err := decodeChecked(data, 0, opts, 0)
+	if err != nil {
+		return nil, err
+	}
 
-			i += (prevPos - pos)
-			pos = prevPos
+	if pos != uint64(len(data)) {
+		return nil, &RlpError{ErrRlpTrailing, pos}
+	}
+
+	return val, nil
+}
+
+// Like DecodeValue but applies the options' limits
+func (opts *DecodeOptions) DecodeValue(rlpData []byte) (*Value, error) {
+	if len(rlpData) != 0 {
+		data, err := opts.DecodeBytes(rlpData)
+		if err != nil {
+			return nil, err
 		}
-		return slice, pos
 
-	default:
-		panic(fmt.Sprintf("byte not supported: %q", char))
+		return NewValue(data), nil
 	}
 
-	return slice, 0
+	return NewValue(nil), nil
+}
+
+// Decodes a single RLP item spanning the whole of data. Unlike Decode it never
+// panics; malformed input is reported as an *RlpError.
+func DecodeBytes(data []byte) (interface{}, error) {
+	return DefaultDecodeOptions.DecodeBytes(data)
+}
+
+// Like DecodeBytes but also rejects data which isn't canonically encoded, that
+// is data which Encode wouldn't produce for the decoded value. Hashes computed
+// over such data don't match the hashes computed by other nodes.
+func DecodeStrict(data []byte) (interface{}, error) {
+	opts := DefaultDecodeOptions
+	opts.Strict = true
+
+	return opts.DecodeBytes(data)
+}
+
+// Checks whether data is a single, canonically encoded RLP item. Returns nil
+// if it is or the *RlpError describing the first violation.
+func IsCanonical(data []byte) error {
+	_, err := DecodeStrict(data)
+
+	return err
 }
 
 var (
@@ -330,75 +398,3 @@ var (
 	numberRlp = big.NewInt(0xb7)
 	zeroRlp   = big.NewInt(0x0)
 )
-
-func Encode(object interface{}) []byte {
-	var buff bytes.Buffer
-
-	if object != nil {
-		switch t := object.(type) {
-		case *RlpValue:
-			buff.Write(Encode(t.AsRaw()))
-		// Code dup :-/
-		case int:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case uint:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case int8:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case int16:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case int32:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case int64:
-			buff.Write(Encode(big.NewInt(t)))
-		case uint16:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case uint32:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case uint64:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case byte:
-			buff.Write(Encode(big.NewInt(int64(t))))
-		case *big.Int:
-			buff.Write(Encode(t.Bytes()))
-		case []byte:
-			if len(t) == 1 && t[0] <= 0x7f {
-				buff.Write(t)
-			} else if len(t) < 56 {
-				buff.WriteByte(byte(len(t) + 0x80))
-				buff.Write(t)
-			} else {
-				var b bytes.Buffer
-				binary.Write(&b, binary.BigEndian, len(t))
-				buff.WriteByte(byte(len(b.Bytes()) + 0xb7))
-				buff.Write(b.Bytes())
-				buff.Write(t)
-			}
-		case string:
-			buff.Write(Encode([]byte(t)))
-		case []interface{}:
-			// Inline function for writing the slice header
-			WriteSliceHeader := func(length int) {
-				if length < 56 {
-					buff.WriteByte(byte(length + 0xc0))
-				} else {
-					b := big.NewInt(int64(length))
-					buff.WriteByte(byte(len(b.Bytes()) + 0xf7))
-					buff.Write(b.Bytes())
-				}
-			}
-
-			var b bytes.Buffer
-			for _, val := range t {
-				b.Write(Encode(val))
-			}
-			WriteSliceHeader(len(b.Bytes()))
-			buff.Write(b.Bytes())
-		}
-	} else {
-		// Empty list for nil
-		buff.WriteByte(0x80)
-	}
-
-	return buff.Bytes()
-}
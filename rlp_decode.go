@@ -0,0 +1,353 @@
+package ethutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+)
+
+// Errors returned by the typed decoder in addition to the stream errors.
+// Like those they're reported in an *RlpDecodeError.
+var (
+	ErrRlpTooFewElements  = errors.New("too few elements")
+	ErrRlpTooManyElements = errors.New("too many elements")
+	ErrRlpBoolValue       = errors.New("invalid boolean value")
+)
+
+// RlpDecodeError is returned when the input doesn't fit the type it's decoded in to
+type RlpDecodeError struct {
+	Err  error
+	Pos  uint64
+	Type reflect.Type
+}
+
+func (err *RlpDecodeError) Error() string {
+	return fmt.Sprintf("rlp: %v at offset %d decoding %v", err.Err, err.Pos, err.Type)
+}
+
+// Decodes a single RLP item spanning the whole of data in to target, which
+// must be a non-nil pointer. Decoding follows the rules of EncodeToBytes in
+// reverse: lists are decoded in to structs, slices and arrays, strings in to
+// strings, byte slices and arrays, *big.Int and integers. Types implementing
+// EthDecoder, or whose pointer type does, decode themselves with DecodeRLP
+// and a RawValue receives the item as is. A target of type interface{}
+// receives the same values Decode returns.
+func DecodeInto(data []byte, target interface{}) error {
+	s := NewStream(bytes.NewReader(data), uint64(len(data)))
+	if err := s.Decode(target); err != nil {
+		return err
+	}
+
+	if _, _, err := s.Kind(); err != io.EOF {
+		return &RlpError{ErrRlpTrailing, s.headerAt}
+	}
+
+	return nil
+}
+
+// Decodes the next item in to target, see DecodeInto
+func (s *Stream) Decode(target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("rlp: decode target must be a non-nil pointer, got %T", target)
+	}
+
+	return s.decodeValue(v.Elem())
+}
+
+// Turns stream errors in to errors mentioning the type being decoded
+func (s *Stream) typeError(err error, typ reflect.Type) error {
+	switch e := err.(type) {
+	case *RlpError:
+		return &RlpDecodeError{e.Err, e.Pos, typ}
+	case *RlpDecodeError:
+		// Already reported for a nested type
+		return err
+	}
+
+	if err == ErrRlpEOL {
+		return &RlpDecodeError{ErrRlpTooFewElements, s.pos, typ}
+	} else if err == io.EOF {
+		return &RlpDecodeError{ErrRlpTruncated, s.pos, typ}
+	}
+
+	return err
+}
+
+func (s *Stream) decodeValue(v reflect.Value) error {
+	typ := v.Type()
+
+	switch {
+	case typ.Kind() == reflect.Ptr && typ.Implements(ethDecoderType):
+		if v.IsNil() {
+			v.Set(reflect.New(typ.Elem()))
+		}
+
+		return s.typeError(v.Interface().(EthDecoder).DecodeRLP(s), typ)
+	case reflect.PtrTo(typ).Implements(ethDecoderType):
+		return s.typeError(v.Addr().Interface().(EthDecoder).DecodeRLP(s), typ)
+	case typ == rawValueType:
+		raw, err := s.Raw()
+		if err != nil {
+			return s.typeError(err, typ)
+		}
+		v.SetBytes(raw)
+
+		return nil
+	case typ == bigIntType:
+		return s.decodeBigInt(v.Addr().Interface().(*big.Int))
+	case typ.Kind() == reflect.Ptr && typ.Elem() == bigIntType:
+		if v.IsNil() {
+			v.Set(reflect.New(bigIntType))
+		}
+
+		return s.decodeBigInt(v.Interface().(*big.Int))
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		num, err := s.Uint()
+		if err != nil {
+			return s.typeError(err, typ)
+		} else if num > 1 {
+			return &RlpDecodeError{ErrRlpBoolValue, s.headerAt, typ}
+		}
+		v.SetBool(num == 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		num, err := s.Uint()
+		if err != nil {
+			return s.typeError(err, typ)
+		} else if v.OverflowUint(num) {
+			return &RlpDecodeError{ErrRlpUintOverflow, s.headerAt, typ}
+		}
+		v.SetUint(num)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, err := s.Uint()
+		if err != nil {
+			return s.typeError(err, typ)
+		} else if num > 1<<63-1 || v.OverflowInt(int64(num)) {
+			return &RlpDecodeError{ErrRlpUintOverflow, s.headerAt, typ}
+		}
+		v.SetInt(int64(num))
+	case reflect.String:
+		b, err := s.Bytes()
+		if err != nil {
+			return s.typeError(err, typ)
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			b, err := s.Bytes()
+			if err != nil {
+				return s.typeError(err, typ)
+			}
+			v.SetBytes(b)
+
+			return nil
+		}
+
+		return s.decodeList(v)
+	case reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			b, err := s.Bytes()
+			if err != nil {
+				return s.typeError(err, typ)
+			}
+
+			if len(b) < v.Len() {
+				return &RlpDecodeError{ErrRlpTooFewElements, s.headerAt, typ}
+			} else if len(b) > v.Len() {
+				return &RlpDecodeError{ErrRlpTooManyElements, s.headerAt, typ}
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+
+			return nil
+		}
+
+		return s.decodeList(v)
+	case reflect.Struct:
+		return s.decodeStruct(v)
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(typ.Elem()))
+		}
+
+		return s.decodeValue(v.Elem())
+	case reflect.Interface:
+		if typ.NumMethod() != 0 {
+			return fmt.Errorf("rlp: can't decode in to non-empty interface %v", typ)
+		}
+
+		val, err := s.decodeInterface()
+		if err != nil {
+			return s.typeError(err, typ)
+		}
+
+		if val == nil {
+			v.Set(reflect.Zero(typ))
+		} else {
+			v.Set(reflect.ValueOf(val))
+		}
+	default:
+		return fmt.Errorf("rlp: type %v is not RLP-serializable", typ)
+	}
+
+	return nil
+}
+
+func (s *Stream) decodeBigInt(num *big.Int) error {
+	b, err := s.Bytes()
+	if err != nil {
+		return s.typeError(err, reflect.TypeOf(num))
+	}
+	num.SetBytes(b)
+
+	return nil
+}
+
+// Decodes a list in to a slice or an array. Arrays must be filled exactly.
+func (s *Stream) decodeList(v reflect.Value) error {
+	if _, err := s.List(); err != nil {
+		return s.typeError(err, v.Type())
+	}
+
+	if v.Kind() == reflect.Slice {
+		v.SetLen(0)
+	}
+
+	if err := s.decodeElems(v); err != nil {
+		return err
+	}
+
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	}
+
+	if err := s.ListEnd(); err != nil {
+		return &RlpDecodeError{ErrRlpTooManyElements, s.pos, v.Type()}
+	}
+
+	return nil
+}
+
+// Decodes the remaining elements of the current list in to a slice or array
+func (s *Stream) decodeElems(v reflect.Value) error {
+	for i := 0; ; i++ {
+		_, _, err := s.Kind()
+		if err == ErrRlpEOL {
+			if v.Kind() == reflect.Array && i < v.Len() {
+				return &RlpDecodeError{ErrRlpTooFewElements, s.pos, v.Type()}
+			}
+
+			return nil
+		} else if err != nil {
+			return s.typeError(err, v.Type())
+		}
+
+		if v.Kind() == reflect.Array {
+			if i >= v.Len() {
+				return &RlpDecodeError{ErrRlpTooManyElements, s.headerAt, v.Type()}
+			}
+		} else {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		}
+
+		if err := s.decodeValue(v.Index(i)); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Stream) decodeStruct(v reflect.Value) error {
+	typ := v.Type()
+	fields, err := structFields(typ)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.List(); err != nil {
+		return s.typeError(err, typ)
+	}
+
+	for _, field := range fields {
+		fv := v.Field(field.index)
+		if field.tail {
+			fv.SetLen(0)
+			if err := s.decodeElems(fv); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		kind, size, err := s.Kind()
+		if err != nil {
+			return s.typeError(err, typ)
+		}
+
+		// An empty item decodes to nil
+		if field.nilOK && kind != RlpByte && size == 0 {
+			if kind == RlpList {
+				s.List()
+				err = s.ListEnd()
+			} else {
+				_, err = s.Bytes()
+			}
+			fv.Set(reflect.Zero(fv.Type()))
+		} else {
+			err = s.decodeValue(fv)
+		}
+
+		if err != nil {
+			return s.typeError(err, typ)
+		}
+	}
+
+	if err := s.ListEnd(); err != nil {
+		return &RlpDecodeError{ErrRlpTooManyElements, s.pos, typ}
+	}
+
+	return nil
+}
+
+// Decodes the next item in to the values returned by Decode
+func (s *Stream) decodeInterface() (interface{}, error) {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case RlpByte:
+		b, err := s.Bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		return b[0], nil
+	case RlpString:
+		return s.Bytes()
+	}
+
+	if _, err := s.List(); err != nil {
+		return nil, err
+	}
+
+	var slice []interface{}
+	for {
+		if _, _, err := s.Kind(); err == ErrRlpEOL {
+			break
+		}
+
+		obj, err := s.decodeInterface()
+		if err != nil {
+			return nil, err
+		}
+		slice = append(slice, obj)
+	}
+
+	return slice, s.ListEnd()
+}
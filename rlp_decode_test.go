@@ -0,0 +1,160 @@
+package ethutil
+
+import (
+	"io"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeIntoRoundTrip(t *testing.T) {
+	in := encTestStruct{
+		Nonce: 1024,
+		Flag:  true,
+		Names: []string{"dog", "cat"},
+		Inner: &encTestInner{"horse"},
+		Rest:  []uint{1, 2, 3},
+	}
+
+	var out encTestStruct
+	if err := DecodeInto(Encode(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Expected %#v, got %#v", in, out)
+	}
+}
+
+func TestDecodeIntoTypes(t *testing.T) {
+	var num *big.Int
+	if err := DecodeInto(Encode(big.NewInt(1391787038)), &num); err != nil || num.Int64() != 1391787038 {
+		t.Errorf("Expected 1391787038, got %v (%v)", num, err)
+	}
+
+	var arr [3]byte
+	if err := DecodeInto([]byte("\x83dog"), &arr); err != nil || string(arr[:]) != "dog" {
+		t.Errorf("Expected dog, got %q (%v)", arr, err)
+	}
+
+	var list [][]byte
+	if err := DecodeInto([]byte("\xc8\x83dog\x83cat"), &list); err != nil || len(list) != 2 || string(list[1]) != "cat" {
+		t.Errorf("Expected [dog cat], got %q (%v)", list, err)
+	}
+
+	var generic interface{}
+	if err := DecodeInto([]byte("\xc5\x83dog\x01"), &generic); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []interface{}{[]byte("dog"), byte(1)}; !reflect.DeepEqual(generic, exp) {
+		t.Errorf("Expected %q, got %q", exp, generic)
+	}
+}
+
+func TestDecodeIntoErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		target interface{}
+		err    error
+	}{
+		{"\xc0", new(string), ErrRlpExpectedString},
+		{"\x83dog", new([]uint), ErrRlpExpectedList},
+		{"\x82\x01\x00", new(uint8), ErrRlpUintOverflow},
+		{"\x89\x01\x02\x03\x04\x05\x06\x07\x08\x09", new(uint64), ErrRlpUintOverflow},
+		{"\xc3\x01\x02\x03", new([2]uint), ErrRlpTooManyElements},
+		{"\xc1\x01", new([2]uint), ErrRlpTooFewElements},
+		{"\x82do", new([3]byte), ErrRlpTooFewElements},
+		{"\xc1\x80", new(encTestInner), nil},
+		{"\xc2\x80\x80", new(encTestInner), ErrRlpTooManyElements},
+		{"\xc0", new(encTestInner), ErrRlpTooFewElements},
+		{"\xc2\xc0\x05", new(encTestStruct), ErrRlpExpectedString},
+		{"\x02", new(bool), ErrRlpBoolValue},
+	}
+
+	for _, test := range tests {
+		err := DecodeInto([]byte(test.input), test.target)
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", test.input, err)
+			}
+			continue
+		}
+
+		if decErr, ok := err.(*RlpDecodeError); !ok || decErr.Err != test.err {
+			t.Errorf("%q in to %T: expected %v, got %v", test.input, test.target, test.err, err)
+		}
+	}
+
+	if err := DecodeInto([]byte("\x01\x02"), new(uint)); err == nil {
+		t.Error("Expected an error for trailing data")
+	}
+}
+
+// Encodes as its name in upper case
+type customName string
+
+func (n customName) EncodeRLP(w io.Writer) error {
+	_, err := w.Write(Encode(strings.ToUpper(string(n))))
+
+	return err
+}
+
+func (n *customName) DecodeRLP(s *Stream) error {
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	*n = customName(strings.ToLower(string(b)))
+
+	return nil
+}
+
+// Encodes as a single uint holding both halves
+type customPair struct {
+	Hi, Lo uint8
+}
+
+func (p *customPair) EncodeRLP(w io.Writer) error {
+	_, err := w.Write(Encode(uint(p.Hi)<<8 | uint(p.Lo)))
+
+	return err
+}
+
+func (p *customPair) DecodeRLP(s *Stream) error {
+	num, err := s.Uint()
+	if err != nil {
+		return err
+	}
+	p.Hi, p.Lo = uint8(num>>8), uint8(num)
+
+	return nil
+}
+
+type customHolder struct {
+	Names []customName
+	Pair  customPair
+	Ptr   *customPair
+}
+
+func TestCustomEncoderDecoder(t *testing.T) {
+	in := customHolder{[]customName{"dog", "cat"}, customPair{4, 0}, &customPair{0, 1}}
+
+	enc := Encode(in)
+	if exp := "\xcd\xc8\x83DOG\x83CAT\x82\x04\x00\x01"; string(enc) != exp {
+		t.Errorf("Expected %q, got %q", exp, enc)
+	}
+
+	var out customHolder
+	if err := DecodeInto(enc, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Expected %#v, got %#v", in, out)
+	}
+
+	if enc := Encode((*customPair)(nil)); string(enc) != "\xc0" {
+		t.Errorf("Expected empty list for nil encoder, got %q", enc)
+	}
+}
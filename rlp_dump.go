@@ -0,0 +1,129 @@
+package ethutil
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Renders RLP data as an indented tree, one item per line. Each line shows
+// the offset of the item, its header bytes and form, the payload length and,
+// for strings, the payload as hex and printable ASCII:
+//
+//	0000: c8 short list len=8
+//	0001:   83 short string len=3  636174 |cat|
+//	0005:   83 short string len=3  646f67 |dog|
+//
+// Malformed regions are marked with "!!" and dumped as hex, after which the
+// dump continues with the enclosing list.
+func RlpDump(data []byte) string {
+	d := &dumper{data: data, width: 4}
+	if w := len(fmt.Sprintf("%x", len(data))); w > d.width {
+		d.width = w
+	}
+	d.items(0, uint64(len(data)), 0)
+
+	return d.buf.String()
+}
+
+// Renders the value's RLP encoding, see RlpDump
+func (val *Value) Dump() string {
+	enc, err := EncodeToBytes(val.Val)
+	if err != nil {
+		return fmt.Sprintf("!! %v\n", err)
+	}
+
+	return RlpDump(enc)
+}
+
+type dumper struct {
+	buf   bytes.Buffer
+	data  []byte
+	width int
+}
+
+func (d *dumper) line(pos uint64, depth int, format string, v ...interface{}) {
+	fmt.Fprintf(&d.buf, "%0*x: %s", d.width, pos, strings.Repeat("  ", depth))
+	fmt.Fprintf(&d.buf, format, v...)
+	d.buf.WriteByte('\n')
+}
+
+// Dumps the items between pos and end
+func (d *dumper) items(pos, end uint64, depth int) {
+	for n := 0; pos < end; n++ {
+		if depth == 0 && n == 1 {
+			d.line(pos, depth, "!! %v", ErrRlpTrailing)
+		}
+
+		next, ok := d.item(pos, end, depth)
+		if !ok {
+			return
+		}
+		pos = next
+	}
+}
+
+// Dumps the item at pos, which must end before end. Returns the position of
+// the next item or false if the item is malformed.
+func (d *dumper) item(pos, end uint64, depth int) (uint64, bool) {
+	data := d.data[:end]
+
+	list, start, size, err := readHeader(data, pos, false)
+	if err != nil {
+		if rlpErr, ok := err.(*RlpError); ok {
+			err = rlpErr.Err
+		}
+		d.line(pos, depth, "!! %v: %x", err, data[pos:])
+
+		return end, false
+	}
+
+	var note string
+	if _, _, _, err := readHeader(data, pos, true); err != nil {
+		note = fmt.Sprintf("  !! %v", err.(*RlpError).Err)
+	}
+
+	header, content := data[pos:start], data[start:start+size]
+	switch {
+	case list:
+		d.line(pos, depth, "%x %s len=%d%s", header, dumpForm(data[pos]), size, note)
+		d.items(start, start+size, depth+1)
+	case start == pos:
+		d.line(pos, depth, "%s %s%s", dumpForm(data[pos]), printable(content), note)
+	case size == 0:
+		d.line(pos, depth, "%x %s len=0%s", header, dumpForm(data[pos]), note)
+	default:
+		d.line(pos, depth, "%x %s len=%d  %x %s%s", header, dumpForm(data[pos]), size, content, printable(content), note)
+	}
+
+	return start + size, true
+}
+
+func dumpForm(char byte) string {
+	switch {
+	case char <= 0x7f:
+		return fmt.Sprintf("%02x byte", char)
+	case char <= 0xb7:
+		return "short string"
+	case char <= 0xbf:
+		return "long string"
+	case char <= 0xf7:
+		return "short list"
+	}
+
+	return "long list"
+}
+
+// Renders b as ASCII with anything that isn't printable replaced by a dot
+func printable(b []byte) string {
+	str := make([]byte, len(b))
+	for i, c := range b {
+		if c >= 0x20 && c <= 0x7e {
+			str[i] = c
+		} else {
+			str[i] = '.'
+		}
+	}
+
+	return "|" + string(str) + "|"
+}
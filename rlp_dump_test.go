@@ -0,0 +1,48 @@
+package ethutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRlpDump(t *testing.T) {
+	exp := `0000: cb short list len=11
+0001:   83 short string len=3  646f67 |dog|
+0005:   c5 short list len=5
+0006:     82 short string len=2  0400 |..|
+0009:     02 byte |.|
+000a:     80 short string len=0
+000b:   01 byte |.|
+`
+	res := RlpDump(Encode([]interface{}{"dog", []interface{}{1024, 2, ""}, 1}))
+	if res != exp {
+		t.Errorf("Expected\n%s\ngot\n%s", exp, res)
+	}
+
+	val := NewValue([]interface{}{"dog", []interface{}{1024, 2, ""}, 1})
+	if val.Dump() != exp {
+		t.Errorf("Expected\n%s\ngot\n%s", exp, val.Dump())
+	}
+}
+
+func TestRlpDumpMalformed(t *testing.T) {
+	res := RlpDump([]byte("\xc9\x83dog\x85cat\x01\x81\x05"))
+	lines := strings.Split(strings.TrimSpace(res), "\n")
+
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, got\n%s", res)
+	}
+
+	// The malformed item doesn't stop the dump of the rest of the input
+	if !strings.Contains(lines[2], "!! "+ErrRlpOversized.Error()) {
+		t.Errorf("Expected oversized item to be marked, got %q", lines[2])
+	}
+
+	if !strings.Contains(lines[3], ErrRlpTrailing.Error()) {
+		t.Errorf("Expected trailing bytes to be marked, got %q", lines[3])
+	}
+
+	if !strings.Contains(lines[4], ErrRlpCanonByte.Error()) {
+		t.Errorf("Expected non-canonical byte to be marked, got %q", lines[4])
+	}
+}
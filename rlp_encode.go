@@ -0,0 +1,534 @@
+package ethutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sync"
+)
+
+// RLP has no encoding for negative numbers
+var ErrRlpNegativeInt = errors.New("rlp: can't encode negative integer")
+
+// Encodes object in to RLP. Returns nil if object can't be encoded, e.g. a
+// negative integer, use EncodeToBytes to get the error.
+func Encode(object interface{}) []byte {
+	enc, err := EncodeToBytes(object)
+	if err != nil {
+		return nil
+	}
+
+	return enc
+}
+
+// Encodes object in to RLP. Types implementing EthEncoder, or whose pointer
+// type does, are encoded by their EncodeRLP method; a nil pointer to such a
+// type is encoded as an empty string or list. Besides integers, strings, byte
+// slices and interface slices EncodeToBytes handles bools (encoded as 1 and
+// 0), typed slices and arrays (encoded as lists, unless they're byte arrays),
+// pointers and structs. Pointers are encoded as the value they point to, a
+// nil pointer as an empty string or list depending on the type it points to.
+// Structs are encoded as a list of their exported fields in order. Fields may
+// be tagged with
+//
+//	rlp:"-"    the field is skipped
+//	rlp:"tail" the field must be the last one and a slice; its elements are
+//	           encoded as if they were fields of the struct
+//	rlp:"nil"  the field must be a pointer; it's decoded as nil from an empty
+//	           string or list by DecodeInto
+//
+// Integers, big.Ints included, are encoded as big endian strings without
+// leading zeros. A *Value is encoded as the value it holds. An error is
+// returned for negative integers and for types RLP has no encoding for, e.g.
+// maps.
+func EncodeToBytes(object interface{}) ([]byte, error) {
+	buf := getEncBuffer()
+	defer encBufferPool.Put(buf)
+
+	if err := buf.encode(object); err != nil {
+		return nil, err
+	}
+
+	return buf.appendTo(make([]byte, 0, buf.size())), nil
+}
+
+// Writes the RLP encoding of object to w
+func EncodeToWriter(w io.Writer, object interface{}) error {
+	buf := getEncBuffer()
+	defer encBufferPool.Put(buf)
+
+	if err := buf.encode(object); err != nil {
+		return err
+	}
+
+	return buf.writeTo(w)
+}
+
+// Appends the RLP encoding of object to dst and returns the extended slice.
+// dst is returned as is if object can't be encoded.
+func AppendEncode(dst []byte, object interface{}) []byte {
+	buf := getEncBuffer()
+	defer encBufferPool.Put(buf)
+
+	if err := buf.encode(object); err != nil {
+		return dst
+	}
+
+	return buf.appendTo(dst)
+}
+
+// Returns the size of the RLP encoding of object, 0 if it can't be encoded
+func EncodedSize(object interface{}) int {
+	buf := getEncBuffer()
+	defer encBufferPool.Put(buf)
+
+	if err := buf.encode(object); err != nil {
+		return 0
+	}
+
+	return buf.size()
+}
+
+// encBuffer collects the encoding of a value in a single pass. Strings are
+// appended to str as they're encoded. The size of a list isn't known until all
+// of its elements are encoded, so list headers are kept aside in lheads and
+// only merged with str when the encoding is written out.
+type encBuffer struct {
+	str    []byte
+	lheads []listHead
+	// Total size of all list headers
+	lhsize int
+}
+
+type listHead struct {
+	// Position in str at which the list's payload starts
+	offset int
+	// Size of the list's payload, nested list headers included. Holds the
+	// value of lhsize at the start of the list until the list is complete.
+	size int
+}
+
+var encBufferPool = sync.Pool{
+	New: func() interface{} { return new(encBuffer) },
+}
+
+func getEncBuffer() *encBuffer {
+	buf := encBufferPool.Get().(*encBuffer)
+	buf.str = buf.str[:0]
+	buf.lheads = buf.lheads[:0]
+	buf.lhsize = 0
+
+	return buf
+}
+
+// Size of the complete encoding
+func (w *encBuffer) size() int {
+	return len(w.str) + w.lhsize
+}
+
+// Writes raw, already encoded, data. This lets an EthEncoder write in to the
+// buffer directly.
+func (w *encBuffer) Write(b []byte) (int, error) {
+	w.str = append(w.str, b...)
+
+	return len(b), nil
+}
+
+// Starts a new list and returns its index for listEnd
+func (w *encBuffer) list() int {
+	w.lheads = append(w.lheads, listHead{offset: len(w.str), size: w.lhsize})
+
+	return len(w.lheads) - 1
+}
+
+func (w *encBuffer) listEnd(idx int) {
+	head := &w.lheads[idx]
+	head.size = w.size() - head.offset - head.size
+	w.lhsize += headerSize(head.size)
+}
+
+func (w *encBuffer) appendTo(dst []byte) []byte {
+	pos := 0
+	for _, head := range w.lheads {
+		dst = append(dst, w.str[pos:head.offset]...)
+		dst = appendHeader(dst, head.size, 0xc0)
+		pos = head.offset
+	}
+
+	return append(dst, w.str[pos:]...)
+}
+
+func (w *encBuffer) writeTo(out io.Writer) error {
+	var header [9]byte
+
+	pos := 0
+	for _, head := range w.lheads {
+		if _, err := out.Write(w.str[pos:head.offset]); err != nil {
+			return err
+		}
+
+		if _, err := out.Write(appendHeader(header[:0], head.size, 0xc0)); err != nil {
+			return err
+		}
+		pos = head.offset
+	}
+
+	_, err := out.Write(w.str[pos:])
+
+	return err
+}
+
+func (w *encBuffer) writeBytes(b []byte) {
+	if len(b) == 1 && b[0] <= 0x7f {
+		w.str = append(w.str, b[0])
+	} else {
+		w.str = appendHeader(w.str, len(b), 0x80)
+		w.str = append(w.str, b...)
+	}
+}
+
+func (w *encBuffer) writeString(s string) {
+	if len(s) == 1 && s[0] <= 0x7f {
+		w.str = append(w.str, s[0])
+	} else {
+		w.str = appendHeader(w.str, len(s), 0x80)
+		w.str = append(w.str, s...)
+	}
+}
+
+// Writes i as a big endian string without leading zeros
+func (w *encBuffer) writeUint(i uint64) {
+	if i == 0 {
+		w.str = append(w.str, 0x80)
+	} else if i <= 0x7f {
+		w.str = append(w.str, byte(i))
+	} else {
+		size := intSize(i)
+		w.str = append(w.str, byte(0x80+size))
+		w.str = appendUint(w.str, i, size)
+	}
+}
+
+func (w *encBuffer) writeInt(i int64) error {
+	if i < 0 {
+		return ErrRlpNegativeInt
+	}
+	w.writeUint(uint64(i))
+
+	return nil
+}
+
+func (w *encBuffer) writeBigInt(i *big.Int) error {
+	if i == nil {
+		w.str = append(w.str, 0x80)
+	} else if i.Sign() < 0 {
+		return ErrRlpNegativeInt
+	} else if i.BitLen() <= 64 {
+		w.writeUint(i.Uint64())
+	} else {
+		w.writeBytes(i.Bytes())
+	}
+
+	return nil
+}
+
+func (w *encBuffer) encode(object interface{}) error {
+	if object == nil {
+		// Empty list for nil
+		w.str = append(w.str, 0x80)
+
+		return nil
+	}
+
+	if enc, ok := object.(EthEncoder); ok {
+		if v := reflect.ValueOf(object); v.Kind() == reflect.Ptr && v.IsNil() {
+			w.str = append(w.str, emptyEncoding(v.Type().Elem()))
+
+			return nil
+		}
+
+		return enc.EncodeRLP(w)
+	}
+
+	switch t := object.(type) {
+	case RawValue:
+		// Already encoded
+		w.str = append(w.str, t...)
+	case *Value:
+		if t == nil {
+			return w.encode(nil)
+		}
+
+		return w.encode(t.Val)
+	case *RlpValue:
+		if t == nil {
+			return w.encode(nil)
+		}
+
+		return w.encode(t.Value)
+	case int:
+		return w.writeInt(int64(t))
+	case int8:
+		return w.writeInt(int64(t))
+	case int16:
+		return w.writeInt(int64(t))
+	case int32:
+		return w.writeInt(int64(t))
+	case int64:
+		return w.writeInt(t)
+	case uint:
+		w.writeUint(uint64(t))
+	case byte:
+		w.writeUint(uint64(t))
+	case uint16:
+		w.writeUint(uint64(t))
+	case uint32:
+		w.writeUint(uint64(t))
+	case uint64:
+		w.writeUint(t)
+	case *big.Int:
+		return w.writeBigInt(t)
+	case big.Int:
+		return w.writeBigInt(&t)
+	case []byte:
+		w.writeBytes(t)
+	case string:
+		w.writeString(t)
+	case []interface{}:
+		idx := w.list()
+		for _, val := range t {
+			if err := w.encode(val); err != nil {
+				return err
+			}
+		}
+		w.listEnd(idx)
+	default:
+		return w.encodeReflect(reflect.ValueOf(object))
+	}
+
+	return nil
+}
+
+var (
+	bigIntType     = reflect.TypeOf(big.Int{})
+	rawValueType   = reflect.TypeOf(RawValue{})
+	ethEncoderType = reflect.TypeOf((*EthEncoder)(nil)).Elem()
+	ethDecoderType = reflect.TypeOf((*EthDecoder)(nil)).Elem()
+)
+
+// Encodes the types which aren't covered by encode's type switch
+func (w *encBuffer) encodeReflect(v reflect.Value) error {
+	// Values whose EncodeRLP method has a pointer receiver
+	if reflect.PtrTo(v.Type()).Implements(ethEncoderType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+
+		return ptr.Interface().(EthEncoder).EncodeRLP(w)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			w.str = append(w.str, 0x01)
+		} else {
+			w.str = append(w.str, 0x80)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return w.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.writeUint(v.Uint())
+	case reflect.String:
+		w.writeString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			w.writeByteArray(v)
+
+			return nil
+		}
+
+		idx := w.list()
+		if err := w.encodeElems(v); err != nil {
+			return err
+		}
+		w.listEnd(idx)
+	case reflect.Struct:
+		fields, err := structFields(v.Type())
+		if err != nil {
+			return err
+		}
+
+		idx := w.list()
+		for _, field := range fields {
+			fv := v.Field(field.index)
+			if field.tail {
+				err = w.encodeElems(fv)
+			} else if field.nilOK && fv.IsNil() {
+				w.str = append(w.str, emptyEncoding(fv.Type().Elem()))
+			} else {
+				err = w.encode(fv.Interface())
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+		w.listEnd(idx)
+	case reflect.Ptr:
+		if v.IsNil() {
+			// Encoding the zero value instead would never end for
+			// recursive types
+			w.str = append(w.str, emptyEncoding(v.Type().Elem()))
+
+			return nil
+		}
+
+		return w.encode(v.Elem().Interface())
+	case reflect.Interface:
+		if v.IsNil() {
+			return w.encode(nil)
+		}
+
+		return w.encode(v.Elem().Interface())
+	default:
+		return fmt.Errorf("rlp: type %v is not RLP-serializable", v.Type())
+	}
+
+	return nil
+}
+
+// Writes a byte slice or array of any (named) type
+func (w *encBuffer) writeByteArray(v reflect.Value) {
+	if v.Kind() == reflect.Slice {
+		w.writeBytes(v.Bytes())
+
+		return
+	}
+
+	if v.Len() == 1 && v.Index(0).Uint() <= 0x7f {
+		w.str = append(w.str, byte(v.Index(0).Uint()))
+
+		return
+	}
+
+	w.str = appendHeader(w.str, v.Len(), 0x80)
+	for i := 0; i < v.Len(); i++ {
+		w.str = append(w.str, byte(v.Index(i).Uint()))
+	}
+}
+
+// Encodes the elements of a slice or array without a list header
+func (w *encBuffer) encodeElems(v reflect.Value) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := w.encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Appends the header of a string (offset 0x80) or list (offset 0xc0) payload
+func appendHeader(dst []byte, size int, offset byte) []byte {
+	if size < 56 {
+		return append(dst, offset+byte(size))
+	}
+
+	l := intSize(uint64(size))
+	dst = append(dst, offset+55+byte(l))
+
+	return appendUint(dst, uint64(size), l)
+}
+
+func headerSize(size int) int {
+	if size < 56 {
+		return 1
+	}
+
+	return 1 + intSize(uint64(size))
+}
+
+// Amount of bytes needed to hold i
+func intSize(i uint64) int {
+	size := 1
+	for i >>= 8; i != 0; i >>= 8 {
+		size++
+	}
+
+	return size
+}
+
+// Appends the lowest size bytes of i in big endian order
+func appendUint(dst []byte, i uint64, size int) []byte {
+	for shift := uint(size-1) * 8; ; shift -= 8 {
+		dst = append(dst, byte(i>>shift))
+		if shift == 0 {
+			return dst
+		}
+	}
+}
+
+// The encoding of a nil value of typ; an empty list for list like types and
+// an empty string for everything else
+func emptyEncoding(typ reflect.Type) byte {
+	switch typ.Kind() {
+	case reflect.Struct:
+		if typ != bigIntType {
+			return 0xc0
+		}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() != reflect.Uint8 {
+			return 0xc0
+		}
+	}
+
+	return 0x80
+}
+
+type structField struct {
+	index int
+	tail  bool
+	nilOK bool
+}
+
+// Returns the encoded fields of a struct type and checks their tags
+func structFields(typ reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		// Unexported fields aren't encoded
+		if f.PkgPath != "" {
+			continue
+		}
+
+		field := structField{index: i}
+		switch tag := f.Tag.Get("rlp"); tag {
+		case "":
+		case "-":
+			continue
+		case "tail":
+			if f.Type.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("rlp: tail field %v.%s must be a slice", typ, f.Name)
+			}
+			field.tail = true
+		case "nil":
+			if f.Type.Kind() != reflect.Ptr {
+				return nil, fmt.Errorf("rlp: nil field %v.%s must be a pointer", typ, f.Name)
+			}
+			field.nilOK = true
+		default:
+			return nil, fmt.Errorf("rlp: unknown struct tag %q on %v.%s", tag, typ, f.Name)
+		}
+
+		fields = append(fields, field)
+	}
+
+	for i, field := range fields {
+		if field.tail && i != len(fields)-1 {
+			return nil, fmt.Errorf("rlp: tail field %v.%s must be the last field", typ, typ.Field(field.index).Name)
+		}
+	}
+
+	return fields, nil
+}
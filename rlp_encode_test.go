@@ -0,0 +1,220 @@
+package ethutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+var encodeTests = []interface{}{
+	"",
+	"dog",
+	strings.Repeat("a", 1024),
+	uint(1024),
+	big.NewInt(1391787038),
+	[]interface{}{},
+	[]interface{}{"dog", []interface{}{"cat", []interface{}{}}, 1},
+	[]interface{}{strings.Repeat("a", 56), []interface{}{strings.Repeat("b", 60)}},
+	[]string{"dog", "god", "cat"},
+	encTestStruct{Nonce: 5, Names: []string{"a"}, Rest: []uint{7, 8}},
+}
+
+func TestEncodeOutputs(t *testing.T) {
+	for _, val := range encodeTests {
+		exp := Encode(val)
+
+		if size := EncodedSize(val); size != len(exp) {
+			t.Errorf("%v: EncodedSize returned %d, expected %d", val, size, len(exp))
+		}
+
+		var buf bytes.Buffer
+		if err := EncodeToWriter(&buf, val); err != nil || !bytes.Equal(buf.Bytes(), exp) {
+			t.Errorf("%v: EncodeToWriter wrote %x (%v), expected %x", val, buf.Bytes(), err, exp)
+		}
+
+		res := AppendEncode([]byte("prefix"), val)
+		if !bytes.Equal(res, append([]byte("prefix"), exp...)) {
+			t.Errorf("%v: AppendEncode returned %x, expected prefix + %x", val, res, exp)
+		}
+
+		// Everything in the table decodes back to the generic representation
+		dec, err := DecodeBytes(exp)
+		if err != nil {
+			t.Errorf("%v: can't decode %x: %v", val, exp, err)
+		} else if !bytes.Equal(Encode(dec), exp) {
+			t.Errorf("%v: re-encoding %x gave %x", val, exp, Encode(dec))
+		}
+	}
+}
+
+func TestEncodeUintRoundTrip(t *testing.T) {
+	tests := []uint64{0, 1, 0x7f, 0x80, 1024, 1<<32 - 1, 1<<63 - 1, 1 << 63, 1<<64 - 1}
+
+	for _, num := range tests {
+		enc := Encode(num)
+
+		exp := new(big.Int).SetUint64(num)
+		if val := NewValueFromBytes(enc).BigInt(); val.Cmp(exp) != 0 {
+			t.Errorf("%d: decoded as %v", num, val)
+		}
+
+		var out uint64
+		if err := DecodeInto(enc, &out); err != nil || out != num {
+			t.Errorf("%d: decoded in to %d (%v)", num, out, err)
+		}
+
+		if !bytes.Equal(Encode(exp), enc) {
+			t.Errorf("%d: big.Int encoding %x differs from %x", num, Encode(exp), enc)
+		}
+	}
+
+	// Single bytes decode to a byte
+	for _, num := range []uint64{1, 0x7f} {
+		if val := NewValueFromBytes(Encode(num)).Uint(); val != num {
+			t.Errorf("%d: decoded as %d", num, val)
+		}
+	}
+}
+
+func TestEncodeNegative(t *testing.T) {
+	type named int16
+
+	tests := []interface{}{-1, int8(-1), int64(-1 << 63), big.NewInt(-5), named(-2), []interface{}{1, -1}}
+	for _, val := range tests {
+		if _, err := EncodeToBytes(val); err != ErrRlpNegativeInt {
+			t.Errorf("%v: expected %v, got %v", val, ErrRlpNegativeInt, err)
+		}
+	}
+
+	if enc := Encode(int64(1<<63 - 1)); len(enc) != 9 || enc[0] != 0x88 {
+		t.Errorf("Unexpected encoding %x of MaxInt64", enc)
+	}
+}
+
+func TestEncodeLongList(t *testing.T) {
+	list := make([]interface{}, 20)
+	for i := range list {
+		list[i] = "dog"
+	}
+
+	// 20 * 4 bytes payload
+	res := Encode(list)
+	if !bytes.Equal(res[:2], []byte{0xf8, 80}) || len(res) != 82 {
+		t.Errorf("Expected long list header f850, got %x (len %d)", res[:2], len(res))
+	}
+}
+
+// The recursive encoder used by Encode before it wrote in to a single buffer.
+// Kept as a baseline for the benchmarks below.
+func legacyEncode(object interface{}) []byte {
+	var buff bytes.Buffer
+
+	writeHeader := func(length int, offset byte) {
+		if length < 56 {
+			buff.WriteByte(byte(length) + offset)
+		} else {
+			b := big.NewInt(int64(length))
+			buff.WriteByte(byte(len(b.Bytes())) + offset + 55)
+			buff.Write(b.Bytes())
+		}
+	}
+
+	switch t := object.(type) {
+	case uint64:
+		buff.Write(legacyEncode(big.NewInt(int64(t)).Bytes()))
+	case string:
+		buff.Write(legacyEncode([]byte(t)))
+	case []byte:
+		if len(t) == 1 && t[0] <= 0x7f {
+			buff.Write(t)
+		} else {
+			writeHeader(len(t), 0x80)
+			buff.Write(t)
+		}
+	case []interface{}:
+		var b bytes.Buffer
+		for _, val := range t {
+			b.Write(legacyEncode(val))
+		}
+		writeHeader(b.Len(), 0xc0)
+		buff.Write(b.Bytes())
+	}
+
+	return buff.Bytes()
+}
+
+// Builds a trie like structure of branch nodes holding hashes, nested depth deep
+func benchTrieNode(depth int) []interface{} {
+	node := make([]interface{}, 17)
+	for i := range node {
+		hash := make([]byte, 32)
+		binary.BigEndian.PutUint64(hash, uint64(depth*17+i))
+		node[i] = hash
+	}
+
+	if depth > 0 {
+		node[depth%16] = benchTrieNode(depth - 1)
+	}
+
+	return node
+}
+
+func benchLongList() []interface{} {
+	list := make([]interface{}, 1000)
+	for i := range list {
+		list[i] = []interface{}{uint64(i), "value", []interface{}{uint64(i * 1000)}}
+	}
+
+	return list
+}
+
+func TestLegacyEncodeMatches(t *testing.T) {
+	for _, val := range []interface{}{benchTrieNode(8), benchLongList()} {
+		if !bytes.Equal(legacyEncode(val), Encode(val)) {
+			t.Error("Encode and the legacy encoder disagree")
+		}
+	}
+}
+
+func BenchmarkEncodeTrieNode(b *testing.B) {
+	node := benchTrieNode(8)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Encode(node)
+	}
+}
+
+func BenchmarkLegacyEncodeTrieNode(b *testing.B) {
+	node := benchTrieNode(8)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyEncode(node)
+	}
+}
+
+func BenchmarkEncodeLongList(b *testing.B) {
+	list := benchLongList()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Encode(list)
+	}
+}
+
+func BenchmarkLegacyEncodeLongList(b *testing.B) {
+	list := benchLongList()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyEncode(list)
+	}
+}
+
+func BenchmarkAppendEncodeLongList(b *testing.B) {
+	list := benchLongList()
+	buf := make([]byte, 0, EncodedSize(list))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = AppendEncode(buf[:0], list)
+	}
+}
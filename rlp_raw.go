@@ -0,0 +1,159 @@
+package ethutil
+
+// RawValue holds an encoded RLP item. It lets you look in to encoded data
+// without decoding it in to a tree first. The encoder writes a RawValue out as
+// is and the typed decoder stores the raw encoding of an item in it.
+//
+// Errors returned while splitting report offsets relative to the start of the
+// slice being split.
+type RawValue []byte
+
+// Splits off the first item of b and returns its kind, its content and the
+// bytes following it. The content of a byte is the byte itself.
+func Split(b []byte) (kind RlpKind, content, rest []byte, err error) {
+	list, start, size, err := readHeader(b, 0, false)
+	if err != nil {
+		return 0, nil, b, err
+	}
+
+	end := start + size
+	switch {
+	case list:
+		kind = RlpList
+	case start == 0:
+		kind = RlpByte
+	default:
+		kind = RlpString
+	}
+
+	return kind, b[start:end], b[end:], nil
+}
+
+// Like Split but the first item must be a byte or a string
+func SplitString(b []byte) (content, rest []byte, err error) {
+	kind, content, rest, err := Split(b)
+	if err != nil {
+		return nil, b, err
+	}
+
+	if kind == RlpList {
+		return nil, b, &RlpError{ErrRlpExpectedString, 0}
+	}
+
+	return content, rest, nil
+}
+
+// Like Split but the first item must be a list
+func SplitList(b []byte) (content, rest []byte, err error) {
+	kind, content, rest, err := Split(b)
+	if err != nil {
+		return nil, b, err
+	}
+
+	if kind != RlpList {
+		return nil, b, &RlpError{ErrRlpExpectedList, 0}
+	}
+
+	return content, rest, nil
+}
+
+// Counts the items encoded in b, which usually is the content of a list
+func CountValues(b []byte) (int, error) {
+	count := 0
+	for pos := uint64(0); pos < uint64(len(b)); count++ {
+		_, start, size, err := readHeader(b, pos, false)
+		if err != nil {
+			return 0, err
+		}
+		pos = start + size
+	}
+
+	return count, nil
+}
+
+// Returns the kind and content of the item and the bytes following it
+func (raw RawValue) Kind() (kind RlpKind, content, rest []byte, err error) {
+	return Split(raw)
+}
+
+// Returns the amount of items in the list
+func (raw RawValue) Len() (int, error) {
+	content, _, err := SplitList(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	return CountValues(content)
+}
+
+// Returns the raw idx'th item of the list without decoding the others
+func (raw RawValue) Get(idx int) (RawValue, error) {
+	it, err := NewListIterator(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; it.Next(); i++ {
+		if i == idx {
+			return it.Value(), nil
+		}
+	}
+
+	if it.Err() != nil {
+		return nil, it.Err()
+	}
+
+	return nil, ErrRlpEOL
+}
+
+// Decodes the item in to its Value
+func (raw RawValue) Value() (*Value, error) {
+	return DecodeValue(raw)
+}
+
+// Iterates over the raw items of a list
+type ListIterator struct {
+	data []byte
+	next RawValue
+	err  error
+}
+
+// Creates an iterator over the items of the list encoded in raw
+func NewListIterator(raw RawValue) (*ListIterator, error) {
+	content, _, err := SplitList(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListIterator{data: content}, nil
+}
+
+// Moves to the next item. Returns false at the end of the list or when the
+// next item is malformed, in which case Err tells what went wrong.
+func (it *ListIterator) Next() bool {
+	if len(it.data) == 0 || it.err != nil {
+		return false
+	}
+
+	_, _, rest, err := Split(it.data)
+	if err != nil {
+		it.err = err
+		it.next = nil
+
+		return false
+	}
+
+	it.next = RawValue(it.data[:len(it.data)-len(rest)])
+	it.data = rest
+
+	return true
+}
+
+// The current item, header included
+func (it *ListIterator) Value() RawValue {
+	return it.next
+}
+
+func (it *ListIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,85 @@
+package ethutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRawValueSplit(t *testing.T) {
+	kind, content, rest, err := Split([]byte("\x83dog\x01"))
+	if err != nil || kind != RlpString || string(content) != "dog" || string(rest) != "\x01" {
+		t.Errorf("Unexpected split %v %q %q %v", kind, content, rest, err)
+	}
+
+	kind, content, rest, err = Split(rest)
+	if err != nil || kind != RlpByte || string(content) != "\x01" || len(rest) != 0 {
+		t.Errorf("Unexpected split %v %q %q %v", kind, content, rest, err)
+	}
+
+	if _, _, err := SplitList([]byte("\x83dog")); err == nil {
+		t.Error("Expected an error splitting a string as list")
+	}
+
+	if _, _, _, err := Split([]byte("\x83do")); err == nil {
+		t.Error("Expected an error for truncated input")
+	}
+}
+
+func TestRawValueList(t *testing.T) {
+	node := make([]interface{}, 17)
+	for i := range node {
+		node[i] = bytes.Repeat([]byte{byte(i)}, i)
+	}
+	raw := RawValue(Encode(node))
+
+	if n, err := raw.Len(); err != nil || n != 17 {
+		t.Errorf("Expected 17 items, got %d (%v)", n, err)
+	}
+
+	item, err := raw.Get(3)
+	if err != nil || !bytes.Equal(item, []byte("\x83\x03\x03\x03")) {
+		t.Errorf("Unexpected item %x (%v)", item, err)
+	}
+
+	if _, err := raw.Get(17); err == nil {
+		t.Error("Expected an error for an out of range item")
+	}
+
+	it, err := NewListIterator(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for it.Next() {
+		_, content, _, _ := it.Value().Kind()
+		if len(content) != count {
+			t.Errorf("Item %d has content %x", count, content)
+		}
+		count++
+	}
+
+	if count != 17 || it.Err() != nil {
+		t.Errorf("Iterated over %d items (%v)", count, it.Err())
+	}
+}
+
+func TestRawValueEncodeDecode(t *testing.T) {
+	inner := RawValue(Encode([]interface{}{"dog", "cat"}))
+	enc := Encode([]interface{}{inner, "horse"})
+	if !bytes.Equal(enc, Encode([]interface{}{[]interface{}{"dog", "cat"}, "horse"})) {
+		t.Errorf("RawValue wasn't written as is: %x", enc)
+	}
+
+	var out struct {
+		Inner RawValue
+		Name  string
+	}
+	if err := DecodeInto(enc, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(out.Inner, inner) || out.Name != "horse" {
+		t.Errorf("Unexpected result %x %q", out.Inner, out.Name)
+	}
+}
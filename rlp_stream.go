@@ -0,0 +1,325 @@
+package ethutil
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+)
+
+// The kind of an RLP item
+type RlpKind int
+
+const (
+	RlpByte RlpKind = iota
+	RlpString
+	RlpList
+)
+
+func (kind RlpKind) String() string {
+	switch kind {
+	case RlpByte:
+		return "byte"
+	case RlpString:
+		return "string"
+	case RlpList:
+		return "list"
+	}
+
+	return "unknown"
+}
+
+// Errors returned by the stream decoder. Apart from ErrRlpEOL and ErrRlpNotInList
+// they're wrapped in an *RlpError holding the offset of the item's header.
+var (
+	ErrRlpEOL            = errors.New("end of list")
+	ErrRlpNotInList      = errors.New("not in a list")
+	ErrRlpNotAtEOL       = errors.New("list has unread elements")
+	ErrRlpExpectedString = errors.New("expected string or byte")
+	ErrRlpExpectedList   = errors.New("expected list")
+	ErrRlpUintOverflow   = errors.New("uint overflow")
+	ErrRlpItemTooLarge   = errors.New("item exceeds maximum size")
+)
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Stream decodes RLP items one at a time from a reader without loading the
+// whole input in to memory. Lists are entered with List and left again with
+// ListEnd, every other item is consumed by Bytes, Uint or Raw.
+//
+// Readers which don't implement io.ByteReader are buffered, which means the
+// stream may read past the last item it decodes.
+type Stream struct {
+	r       byteReader
+	maxSize uint64
+
+	// Amount of bytes consumed so far
+	pos uint64
+	// Remaining bytes of each list entered
+	stack []uint64
+
+	// The header of the item at the current position. It's read once by
+	// Kind and kept until the item is consumed.
+	haveKind bool
+	kind     RlpKind
+	size     uint64
+	kindErr  error
+	header   []byte
+	headerAt uint64
+}
+
+// Creates a new stream reading from r. Items which have a payload larger than
+// maxSize are rejected with ErrRlpItemTooLarge; a maxSize of 0 means no limit.
+func NewStream(r io.Reader, maxSize uint64) *Stream {
+	stream := &Stream{maxSize: maxSize}
+	if br, ok := r.(byteReader); ok {
+		stream.r = br
+	} else {
+		stream.r = bufio.NewReader(r)
+	}
+
+	return stream
+}
+
+// Returns the kind and payload size of the next item without consuming it.
+// A byte is its own header and has a size of 0. At the end of a list Kind
+// returns ErrRlpEOL and at the end of the input io.EOF.
+func (s *Stream) Kind() (RlpKind, uint64, error) {
+	if !s.haveKind {
+		s.kind, s.size, s.kindErr = s.readKind()
+		s.haveKind = true
+	}
+
+	return s.kind, s.size, s.kindErr
+}
+
+func (s *Stream) readKind() (RlpKind, uint64, error) {
+	if len(s.stack) > 0 && s.stack[len(s.stack)-1] == 0 {
+		return 0, 0, ErrRlpEOL
+	}
+
+	s.header = s.header[:0]
+	s.headerAt = s.pos
+
+	char, err := s.readByte()
+	if err == io.EOF {
+		// Running out of input is only expected in between items
+		if len(s.stack) == 0 {
+			return 0, 0, io.EOF
+		}
+
+		return 0, 0, &RlpError{ErrRlpTruncated, s.headerAt}
+	} else if err != nil {
+		return 0, 0, err
+	}
+	s.header = append(s.header, char)
+
+	var kind RlpKind
+	var size uint64
+	switch {
+	case char <= 0x7f:
+		return RlpByte, 0, nil
+	case char <= 0xb7:
+		kind, size = RlpString, uint64(char-0x80)
+	case char <= 0xbf:
+		kind = RlpString
+		size, err = s.readSize(int(char - 0xb7))
+	case char <= 0xf7:
+		kind, size = RlpList, uint64(char-0xc0)
+	default:
+		kind = RlpList
+		size, err = s.readSize(int(char - 0xf7))
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if s.maxSize > 0 && size > s.maxSize {
+		return 0, 0, &RlpError{ErrRlpItemTooLarge, s.headerAt}
+	}
+
+	if len(s.stack) > 0 && size > s.stack[len(s.stack)-1] {
+		return 0, 0, &RlpError{ErrRlpOversized, s.headerAt}
+	}
+
+	return kind, size, nil
+}
+
+// Reads the big endian size of l bytes following a long form header
+func (s *Stream) readSize(l int) (uint64, error) {
+	buf, err := s.read(uint64(l))
+	if err != nil {
+		return 0, err
+	}
+	s.header = append(s.header, buf...)
+
+	var size uint64
+	for _, b := range buf {
+		size = size<<8 | uint64(b)
+	}
+
+	return size, nil
+}
+
+// Reads the first byte of a header. The caller makes sure the current list
+// isn't exhausted.
+func (s *Stream) readByte() (byte, error) {
+	if len(s.stack) > 0 {
+		s.stack[len(s.stack)-1]--
+	}
+
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	s.pos++
+
+	return b, nil
+}
+
+// Reads exactly n bytes of the current item. The buffer grows as data
+// arrives so a bogus size can't make us allocate more than is available.
+func (s *Stream) read(n uint64) ([]byte, error) {
+	if len(s.stack) > 0 {
+		if n > s.stack[len(s.stack)-1] {
+			return nil, &RlpError{ErrRlpOversized, s.headerAt}
+		}
+		s.stack[len(s.stack)-1] -= n
+	}
+
+	// CopyN takes an int64, larger sizes can't be backed by any input
+	if n > math.MaxInt64 {
+		return nil, &RlpError{ErrRlpOversized, s.headerAt}
+	}
+
+	var buf bytes.Buffer
+	m, err := io.CopyN(&buf, s.r, int64(n))
+	s.pos += uint64(m)
+	if err == io.EOF || (err == nil && uint64(m) != n) {
+		return nil, &RlpError{ErrRlpTruncated, s.headerAt}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Marks the current item as consumed so the next call to Kind reads a new header
+func (s *Stream) consumed() {
+	s.haveKind = false
+}
+
+// Reads the next item, which must be a byte or a string, and returns its payload
+func (s *Stream) Bytes() ([]byte, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case RlpByte:
+		s.consumed()
+
+		return []byte{s.header[0]}, nil
+	case RlpString:
+		b, err := s.read(size)
+		if err != nil {
+			return nil, err
+		}
+		s.consumed()
+
+		return b, nil
+	}
+
+	return nil, &RlpError{ErrRlpExpectedString, s.headerAt}
+}
+
+// Reads the next item as a big endian unsigned integer of at most 8 bytes
+func (s *Stream) Uint() (uint64, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return 0, err
+	}
+
+	if kind == RlpString && size > 8 {
+		return 0, &RlpError{ErrRlpUintOverflow, s.headerAt}
+	}
+
+	b, err := s.Bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	var num uint64
+	for _, c := range b {
+		num = num<<8 | uint64(c)
+	}
+
+	return num, nil
+}
+
+// Reads the next item, header included, as raw RLP data
+func (s *Stream) Raw() ([]byte, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := append([]byte{}, s.header...)
+	if kind != RlpByte {
+		payload, err := s.read(size)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, payload...)
+	}
+	s.consumed()
+
+	return raw, nil
+}
+
+// Enters the next item, which must be a list, and returns the size of its
+// payload. The list's elements are read with the usual methods until Kind
+// returns ErrRlpEOL, after which ListEnd must be called.
+func (s *Stream) List() (uint64, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return 0, err
+	}
+
+	if kind != RlpList {
+		return 0, &RlpError{ErrRlpExpectedList, s.headerAt}
+	}
+
+	// The list's payload is accounted for by its own entry on the stack
+	if len(s.stack) > 0 {
+		s.stack[len(s.stack)-1] -= size
+	}
+	s.stack = append(s.stack, size)
+	s.consumed()
+
+	return size, nil
+}
+
+// Leaves the list entered by the last call to List. All of its elements must
+// have been read.
+func (s *Stream) ListEnd() error {
+	if len(s.stack) == 0 {
+		return ErrRlpNotInList
+	}
+
+	// Kind already took a peeked element's header off the list's size
+	if s.stack[len(s.stack)-1] > 0 || (s.haveKind && s.kindErr == nil) {
+		return &RlpError{ErrRlpNotAtEOL, s.pos}
+	}
+
+	s.stack = s.stack[:len(s.stack)-1]
+	s.consumed()
+
+	return nil
+}
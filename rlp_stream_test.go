@@ -0,0 +1,96 @@
+package ethutil
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestStreamList(t *testing.T) {
+	s := NewStream(bytes.NewReader([]byte("\xca\x83dog\xc4\x83cat\x05")), 0)
+
+	if _, err := s.List(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := s.Bytes()
+	if err != nil || string(b) != "dog" {
+		t.Errorf("Expected dog, got %q (%v)", b, err)
+	}
+
+	if _, err := s.List(); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = s.Bytes()
+	if string(b) != "cat" {
+		t.Errorf("Expected cat, got %q", b)
+	}
+	if _, _, err := s.Kind(); err != ErrRlpEOL {
+		t.Errorf("Expected end of list, got %v", err)
+	}
+	if err := s.ListEnd(); err != nil {
+		t.Fatal(err)
+	}
+
+	num, err := s.Uint()
+	if err != nil || num != 5 {
+		t.Errorf("Expected 5, got %d (%v)", num, err)
+	}
+
+	if err := s.ListEnd(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Kind(); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+}
+
+func TestStreamMultipleItems(t *testing.T) {
+	s := NewStream(bytes.NewReader([]byte("\x82\x04\x00\xc2\x01\x02\x80")), 0)
+
+	var raws [][]byte
+	for {
+		raw, err := s.Raw()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		raws = append(raws, raw)
+	}
+
+	if len(raws) != 3 || string(raws[1]) != "\xc2\x01\x02" {
+		t.Errorf("Unexpected items %q", raws)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		maxSize uint64
+		read    func(*Stream) error
+		err     error
+	}{
+		{"\x83dog", 2, func(s *Stream) error { _, err := s.Bytes(); return err }, ErrRlpItemTooLarge},
+		{"\x83do", 0, func(s *Stream) error { _, err := s.Bytes(); return err }, ErrRlpTruncated},
+		{"\xc1\x83dog", 0, func(s *Stream) error { s.List(); _, err := s.Bytes(); return err }, ErrRlpOversized},
+		{"\xc2\x01\x02", 0, func(s *Stream) error { s.List(); s.Uint(); return s.ListEnd() }, ErrRlpNotAtEOL},
+		// Elements which were only peeked at with Kind are unread as well
+		{"\xc1\x05", 0, func(s *Stream) error { s.List(); s.Kind(); return s.ListEnd() }, ErrRlpNotAtEOL},
+		{"\xc1\x80", 0, func(s *Stream) error { s.List(); s.Kind(); return s.ListEnd() }, ErrRlpNotAtEOL},
+		{"\xc1\xc0", 0, func(s *Stream) error { s.List(); s.Kind(); return s.ListEnd() }, ErrRlpNotAtEOL},
+		{"\x89\x01\x02\x03\x04\x05\x06\x07\x08\x09", 0, func(s *Stream) error { _, err := s.Uint(); return err }, ErrRlpUintOverflow},
+		{"\xc0", 0, func(s *Stream) error { _, err := s.Bytes(); return err }, ErrRlpExpectedString},
+		{"\x83dog", 0, func(s *Stream) error { _, err := s.List(); return err }, ErrRlpExpectedList},
+		// Sizes which don't fit in an int64
+		{"\xbf\x80\x00\x00\x00\x00\x00\x00\x00abc", 0, func(s *Stream) error { _, err := s.Bytes(); return err }, ErrRlpOversized},
+		{"\xbf\xff\xff\xff\xff\xff\xff\xff\xffabc", 0, func(s *Stream) error { _, err := s.Raw(); return err }, ErrRlpOversized},
+	}
+
+	for i, test := range tests {
+		err := test.read(NewStream(bytes.NewReader([]byte(test.input)), test.maxSize))
+		if rlpErr, ok := err.(*RlpError); !ok || rlpErr.Err != test.err {
+			t.Errorf("test %d: expected %v, got %v", i, test.err, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,6 +22,164 @@ func TestRlpValueEncoding(t *testing.T) {
 	}
 }
 
+func TestValueNestedEncoding(t *testing.T) {
+	val := EmptyValue()
+	val.AppendList().Append(1).Append(2)
+	val.Append(NewValue("3")).Append(NewRlpValue([]interface{}{4}))
+
+	exp := Encode([]interface{}{[]interface{}{1, 2}, "3", []interface{}{4}})
+	if res := val.Encode(); !bytes.Equal(res, exp) {
+		t.Errorf("expected %q, got %q", exp, res)
+	}
+
+	if res := Encode(val); !bytes.Equal(res, exp) {
+		t.Errorf("Encode(*Value): expected %q, got %q", exp, res)
+	}
+
+	if val.Get(0).Len() != 2 || val.Get(0).Get(1).Raw() != 2 || val.Get(1).Str() != "3" {
+		t.Errorf("nested values aren't unwrapped by Get: %v", val.Val)
+	}
+
+	if inner := NewValue(NewValue(5)); inner.Val != 5 {
+		t.Errorf("expected NewValue to unwrap, got %T", inner.Val)
+	}
+}
+
+func TestRlpValueDelegates(t *testing.T) {
+	val := NewRlpValue([]interface{}{uint16(1024), []byte{4, 0}, "dog"})
+
+	if val.Get(0).AsBigInt().Int64() != 1024 || val.Get(1).AsBigInt().Int64() != 1024 {
+		t.Errorf("expected 1024 for both, got %v and %v", val.Get(0).AsBigInt(), val.Get(1).AsBigInt())
+	}
+
+	if val.Length() != 3 || val.Get(2).Length() != 0 || val.Get(2).AsString() != "dog" {
+		t.Errorf("unexpected lengths %d, %d", val.Length(), val.Get(2).Length())
+	}
+}
+
+func TestValueIntegers(t *testing.T) {
+	if num := NewValueFromBytes(Encode(1024)).Uint(); num != 1024 {
+		t.Errorf("Expected 1024, got %d", num)
+	}
+
+	val := NewValueFromBytes(Encode([]interface{}{uint64(1<<64 - 1), 7, []byte{1, 0, 0, 0, 0, 0, 0, 0, 0}, "dog"}))
+	if num, err := val.Get(0).TryUint(); err != nil || num != 1<<64-1 {
+		t.Errorf("Expected MaxUint64, got %d (%v)", num, err)
+	}
+	if num, err := val.Get(0).TryInt(); valueErr(err) != ErrValueOverflow {
+		t.Errorf("Expected overflow, got %d (%v)", num, err)
+	}
+	if num := val.Get(1).Int(); num != 7 {
+		t.Errorf("Expected 7, got %d", num)
+	}
+	if num, err := val.Get(2).TryUint(); valueErr(err) != ErrValueOverflow || val.Get(2).Uint() != 0 {
+		t.Errorf("Expected overflow, got %d (%v)", num, err)
+	}
+	if val.Get(2).BigInt().BitLen() != 65 {
+		t.Errorf("Expected 2^64, got %v", val.Get(2).BigInt())
+	}
+	if _, err := val.TryUint(); valueErr(err) != ErrValueNotInteger {
+		t.Errorf("Expected %v for a list, got %v", ErrValueNotInteger, err)
+	}
+
+	if b, err := NewValue(uint(256)).TryByte(); valueErr(err) != ErrValueOverflow {
+		t.Errorf("Expected overflow, got %d (%v)", b, err)
+	}
+	if num := NewValue(-3).Int(); num != -3 {
+		t.Errorf("Expected -3, got %d", num)
+	}
+	if _, err := NewValue(-3).TryUint(); valueErr(err) != ErrValueOverflow {
+		t.Errorf("Expected overflow for a negative int, got %v", err)
+	}
+}
+
+func TestValueByteStr(t *testing.T) {
+	val := NewValueFromBytes([]byte("\xc2\x20\x01"))
+
+	if str := val.Get(0).Str(); str != " " {
+		t.Errorf("Expected a single space, got %q", str)
+	}
+
+	if b := val.Get(1).Bytes(); !bytes.Equal(b, []byte{1}) {
+		t.Errorf("Expected 01, got %x", b)
+	}
+}
+
+func TestValueMutate(t *testing.T) {
+	tx := NewValueFromBytes(Encode([]interface{}{1, "to", 100, "sig"}))
+
+	// Bump the nonce and strip the signature
+	if err := tx.Set(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Truncate(3); err != nil {
+		t.Fatal(err)
+	}
+	if exp := Encode([]interface{}{2, "to", 100}); !bytes.Equal(tx.Encode(), exp) {
+		t.Errorf("Expected %x, got %x", exp, tx.Encode())
+	}
+
+	list := NewValue([]interface{}{"b"})
+	list.Insert(0, "a")
+	list.Insert(2, "d")
+	list.Insert(2, "c")
+	list.Remove(1)
+	if exp := Encode([]interface{}{"a", "c", "d"}); !bytes.Equal(list.Encode(), exp) {
+		t.Errorf("Expected %x, got %x", exp, list.Encode())
+	}
+
+	errs := []error{list.Set(3, 0), list.Set(-1, 0), list.Insert(4, 0), list.Remove(3), list.Truncate(-1), NewValue("a").Set(0, 0)}
+	for i, exp := range []error{ErrValueIndex, ErrValueIndex, ErrValueIndex, ErrValueIndex, ErrValueIndex, ErrValueExpectedList} {
+		if valueErr(errs[i]) != exp {
+			t.Errorf("%d: expected %v, got %v", i, exp, errs[i])
+		}
+	}
+
+	if !list.Get(-1).IsNil() {
+		t.Error("Expected nil for a negative index")
+	}
+
+	// Changing the length of a child leaves the parent alone
+	for _, parent := range []*Value{
+		NewValue([]interface{}{[]interface{}{"a", "b", "c"}}),
+		NewValueFromBytes(Encode([]interface{}{[]interface{}{"a", "b", "c"}})),
+	} {
+		exp := parent.Encode()
+		parent.Get(0).Remove(0)
+		parent.Get(0).Insert(0, "z")
+		parent.Get(0).Truncate(1)
+		if !bytes.Equal(parent.Encode(), exp) {
+			t.Errorf("Expected %x, got %x", exp, parent.Encode())
+		}
+
+		inner := parent.Get(0)
+		inner.Remove(0)
+		parent.Set(0, inner)
+		inner.Set(0, "x")
+		if exp := Encode([]interface{}{[]interface{}{"x", "c"}}); !bytes.Equal(parent.Encode(), exp) {
+			t.Errorf("Expected %x, got %x", exp, parent.Encode())
+		}
+	}
+}
+
+func TestValueDeepCopy(t *testing.T) {
+	val := NewValueFromBytes(Encode([]interface{}{"dog", []interface{}{"cat"}}))
+	val.AppendList().Append("horse")
+	exp := val.Encode()
+
+	cpy := val.DeepCopy()
+	cpy.Get(0).Bytes()[0] = 'f'
+	cpy.Get(1).Set(0, "bat")
+	cpy.Get(2).Set(0, "pony")
+
+	if !bytes.Equal(val.Encode(), exp) {
+		t.Errorf("Changing the copy changed the original to %q", val.Encode())
+	}
+	if e := Encode([]interface{}{"fog", []interface{}{"bat"}, []interface{}{"pony"}}); !bytes.Equal(cpy.Encode(), e) {
+		t.Errorf("Expected %q, got %q", e, cpy.Encode())
+	}
+}
+
 func TestValueSlice(t *testing.T) {
 	val := []interface{}{
 		"value1",
@@ -81,6 +240,101 @@ func TestEncode(t *testing.T) {
 	}
 }
 
+type encTestInner struct {
+	Name string
+}
+
+// Encoding a nil Next mustn't recurse forever
+type encTestNode struct {
+	V    uint
+	Next *encTestNode
+}
+
+type encTestStruct struct {
+	Nonce   uint64
+	Flag    bool
+	Names   []string
+	Inner   *encTestInner
+	Parent  *encTestInner `rlp:"nil"`
+	Ignored string        `rlp:"-"`
+	secret  string
+	Rest    []uint `rlp:"tail"`
+}
+
+func TestEncodeReflect(t *testing.T) {
+	tests := []struct {
+		val interface{}
+		exp string
+	}{
+		{true, "01"},
+		{false, "80"},
+		{[]string{"dog", "cat"}, "c883646f6783636174"},
+		{[3]byte{1, 2, 3}, "83010203"},
+		{[]uint16{1, 1024}, "c401820400"},
+		{&encTestInner{"dog"}, "c483646f67"},
+		{(*encTestInner)(nil), "c0"},
+		{(*uint)(nil), "80"},
+		{encTestStruct{Nonce: 5, Flag: true, Names: []string{"a"}, Inner: &encTestInner{"b"}, Rest: []uint{7, 8}}, "c90501c161c162c00708"},
+		{encTestStruct{secret: "x", Ignored: "y"}, "c58080c0c0c0"},
+		{encTestNode{V: 1, Next: &encTestNode{V: 2}}, "c401c202c0"},
+	}
+
+	for _, test := range tests {
+		exp, _ := hex.DecodeString(test.exp)
+		res, err := EncodeToBytes(test.val)
+		if err != nil {
+			t.Errorf("%#v: unexpected error %v", test.val, err)
+		} else if !bytes.Equal(res, exp) {
+			t.Errorf("%#v: expected %x, got %x", test.val, exp, res)
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	type badTail struct {
+		Rest []uint `rlp:"tail"`
+		Last uint
+	}
+
+	tests := []interface{}{
+		map[string]string{},
+		[]interface{}{1, 1.5},
+		badTail{},
+	}
+
+	for _, val := range tests {
+		if _, err := EncodeToBytes(val); err == nil {
+			t.Errorf("%#v: expected an error", val)
+		}
+
+		// The other entry points don't panic on data they can't encode
+		if enc := Encode(val); enc != nil {
+			t.Errorf("%#v: expected no encoding, got %x", val, enc)
+		}
+		if enc := NewValue(val).Encode(); enc != nil {
+			t.Errorf("%#v: expected no encoding, got %x", val, enc)
+		}
+		if enc := AppendEncode([]byte{1}, val); !bytes.Equal(enc, []byte{1}) {
+			t.Errorf("%#v: expected dst unchanged, got %x", val, enc)
+		}
+		if size := EncodedSize(val); size != 0 {
+			t.Errorf("%#v: expected size 0, got %d", val, size)
+		}
+	}
+
+	if enc := NewRlpValue([]interface{}{1, -1}).Encode(); enc != nil {
+		t.Errorf("Expected no encoding for a negative integer, got %x", enc)
+	}
+}
+
+func TestEncodeLongString(t *testing.T) {
+	str := strings.Repeat("a", 56)
+	res := Encode(str)
+	if string(res) != "\xb8\x38"+str {
+		t.Errorf("Expected long form header, got %q", res[:2])
+	}
+}
+
 func TestDecode(t *testing.T) {
 	single := []byte("\x01")
 	b, _ := Decode(single, 0)
@@ -103,6 +357,145 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeBytes(t *testing.T) {
+	b, err := DecodeBytes([]byte("\xcc\x83dog\x83god\x83cat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []interface{}{[]byte("dog"), []byte("god"), []byte("cat")}
+	if !reflect.DeepEqual(b, exp) {
+		t.Errorf("Expected %q, got %q", exp, b)
+	}
+}
+
+func TestDecodeBytesErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+		pos   uint64
+	}{
+		{"", ErrRlpTruncated, 0},
+		{"\xb8", ErrRlpTruncated, 0},
+		{"\xf9\x01", ErrRlpTruncated, 0},
+		{"\xc1\xb9\x01", ErrRlpTruncated, 1},
+		{"\x83do", ErrRlpOversized, 0},
+		{"\xbf\xff\xff\xff\xff\xff\xff\xff\xff", ErrRlpOversized, 0},
+		{"\xc3\x83dog", ErrRlpOversized, 1},
+		{"\xc4\x83dog\x01", ErrRlpTrailing, 5},
+		{"\x01\x02", ErrRlpTrailing, 1},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeBytes([]byte(test.input))
+		rlpErr, ok := err.(*RlpError)
+		if !ok {
+			t.Errorf("%q: expected *RlpError, got %v", test.input, err)
+			continue
+		}
+
+		if rlpErr.Err != test.err || rlpErr.Pos != test.pos {
+			t.Errorf("%q: expected %v at %d, got %v", test.input, test.err, test.pos, err)
+		}
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"\x81\x05", ErrRlpCanonByte},
+		{"\xc2\x81\x7f", ErrRlpCanonByte},
+		{"\xb8\x03dog", ErrRlpCanonSize},
+		{"\xf8\x01\x01", ErrRlpCanonSize},
+		{"\xb9\x00\x38" + strings.Repeat("a", 56), ErrRlpCanonZero},
+	}
+
+	for _, test := range tests {
+		if _, err := DecodeBytes([]byte(test.input)); err != nil {
+			t.Errorf("%q: lenient decoding failed: %v", test.input, err)
+		}
+
+		err := IsCanonical([]byte(test.input))
+		if rlpErr, ok := err.(*RlpError); !ok || rlpErr.Err != test.err {
+			t.Errorf("%q: expected %v, got %v", test.input, test.err, err)
+		}
+	}
+
+	canonical := []string{"\x05", "\x81\x80", "\xb8\x38" + strings.Repeat("a", 56), "\xc4\x83dog"}
+	for _, input := range canonical {
+		if _, err := DecodeStrict([]byte(input)); err != nil {
+			t.Errorf("%q: unexpected error %v", input, err)
+		}
+	}
+}
+
+// Returns n nested empty lists
+func nestedLists(n int) []byte {
+	data := []byte{0xc0}
+	for i := 1; i < n; i++ {
+		data = Encode([]interface{}{RawValue(data)})
+	}
+
+	return data
+}
+
+func TestDecodeOptions(t *testing.T) {
+	opts := &DecodeOptions{MaxDepth: 4, MaxListItems: 3, MaxTotalSize: 16}
+
+	tests := []struct {
+		input []byte
+		err   error
+		pos   uint64
+	}{
+		{nestedLists(4), nil, 0},
+		{nestedLists(5), ErrRlpMaxDepth, 4},
+		{[]byte("\xc3\x01\x02\x03"), nil, 0},
+		{[]byte("\xc4\x01\x02\x03\x04"), ErrRlpMaxListItems, 4},
+		{[]byte("\xc5\xc4\x01\x02\x03\x04"), ErrRlpMaxListItems, 5},
+		{Encode(strings.Repeat("a", 15)), nil, 0},
+		{Encode(strings.Repeat("a", 16)), ErrRlpMaxTotalSize, 16},
+	}
+
+	for i, test := range tests {
+		_, err := opts.DecodeBytes(test.input)
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("test %d: unexpected error %v", i, err)
+			}
+			continue
+		}
+
+		if rlpErr, ok := err.(*RlpError); !ok || rlpErr.Err != test.err || rlpErr.Pos != test.pos {
+			t.Errorf("test %d: expected %v at %d, got %v", i, test.err, test.pos, err)
+		}
+	}
+}
+
+func TestDecodeDefaultDepth(t *testing.T) {
+	deep := nestedLists(DefaultDecodeOptions.MaxDepth + 1)
+
+	if _, err := DecodeValue(deep); err == nil || err.(*RlpError).Err != ErrRlpMaxDepth {
+		t.Errorf("Expected %v, got %v", ErrRlpMaxDepth, err)
+	}
+
+	defer func() {
+		if err, ok := recover().(*RlpError); !ok || err.Err != ErrRlpMaxDepth {
+			t.Errorf("Expected NewValueFromBytes to panic with %v, got %v", ErrRlpMaxDepth, err)
+		}
+	}()
+	NewValueFromBytes(deep)
+}
+
+func TestDecodeLongString(t *testing.T) {
+	str := strings.Repeat("a", 60)
+	b, pos := Decode(Encode([]interface{}{str, "dog"}), 0)
+	if pos != 68 || string(b.([]interface{})[0].([]byte)) != str {
+		t.Errorf("Unexpected decoding %q (pos %d)", b, pos)
+	}
+}
+
 func TestEncodeDecodeBigInt(t *testing.T) {
 	bigInt := big.NewInt(1391787038)
 	encoded := Encode(bigInt)
@@ -21,14 +21,14 @@ func PrintSlice(slice []string) {
 }
 
 func PrintSliceT(slice interface{}) {
-	c := Conv(slice)
-	for i := 0; i < c.Length(); i++ {
-		val := c.Get(i)
+	c := NewValue(slice)
+	for it := c.NewIterator(); it.Next(); {
+		val := it.Value()
 		if val.Type() == reflect.Slice {
-			PrintSliceT(val.AsRaw())
+			PrintSliceT(val.Raw())
 		} else {
-			fmt.Printf("%q", val)
-			if i != c.Length()-1 {
+			fmt.Printf("%q", val.Raw())
+			if it.Idx() != c.Len()-1 {
 				fmt.Printf(",")
 			}
 		}
@@ -37,7 +37,13 @@ func PrintSliceT(slice interface{}) {
 
 // RLP Decodes a node in to a [2] or [17] string slice
 func DecodeNode(data []byte) []string {
-	dec, _ := Decode(data, 0)
+	dec, err := DefaultDecodeOptions.DecodeBytes(data)
+	if err != nil {
+		fmt.Println("Error DecodeNode", err)
+
+		return nil
+	}
+
 	if slice, ok := dec.([]interface{}); ok {
 		strSlice := make([]string, len(slice))
 
@@ -65,6 +71,45 @@ func NewTrie(db Database, Root interface{}) *Trie {
 	return &Trie{db: db, Root: Root}
 }
 
+// The hash of the empty trie
+var EmptyTrieHash = Sha3Bin(Encode(""))
+
+// Opens the trie with the given root hash, as returned by Hash, in db
+func NewTrieFromHash(db Database, hash []byte) *Trie {
+	if string(hash) == string(EmptyTrieHash) {
+		return NewTrie(db, "")
+	}
+
+	return NewTrie(db, hash)
+}
+
+// Returns the 32 byte hash of the root node, EmptyTrieHash for an empty trie
+func (t *Trie) Hash() []byte {
+	if isEmptyNode(t.Root) {
+		return EmptyTrieHash
+	}
+
+	return nodeHash(t.Root)
+}
+
+// Stores the root node in the database, even when it's small enough to be
+// kept inline by Put, such that NewTrieFromHash can open the trie. Returns
+// the hash of the root.
+func (t *Trie) Commit() []byte {
+	if isEmptyNode(t.Root) {
+		t.db.Put(EmptyTrieHash, Encode(""))
+
+		return EmptyTrieHash
+	}
+
+	if _, ok := t.Root.([]interface{}); ok {
+		enc := Encode(t.Root)
+		t.db.Put(Sha3Bin(enc), enc)
+	}
+
+	return t.Hash()
+}
+
 /*
  * Public (query) interface functions
  */
@@ -74,29 +119,36 @@ func (t *Trie) Update(key string, value string) {
 	t.Root = t.UpdateState(t.Root, k, value)
 }
 
+// Removes the key from the trie. Updating a key to "" does the same.
+func (t *Trie) Delete(key string) {
+	k := CompactHexDecode(key)
+
+	t.Root = t.DeleteState(t.Root, k)
+}
+
 func (t *Trie) Get(key string) string {
 	k := CompactHexDecode(key)
-	c := Conv(t.GetState(t.Root, k))
+	c := NewValue(t.GetState(t.Root, k))
 
-	return c.AsString()
+	return c.Str()
 }
 
 func (t *Trie) GetState(node interface{}, key []int) interface{} {
-	n := Conv(node)
+	n := NewValue(node)
 	// Return the node if key is empty (= found)
 	if len(key) == 0 || n.IsNil() {
 		return node
 	}
 
 	currentNode := t.GetNode(node)
-	length := currentNode.Length()
+	length := len(currentNode.Slice())
 
 	if length == 0 {
 		return ""
 	} else if length == 2 {
 		// Decode the key
-		k := CompactDecode(currentNode.Get(0).AsString())
-		v := currentNode.Get(1).AsRaw()
+		k := CompactDecode(currentNode.Get(0).Str())
+		v := currentNode.Get(1).Raw()
 
 		if len(key) >= len(k) && CompareIntSlice(k, key[:len(k)]) {
 			return t.GetState(v, key[len(k):])
@@ -104,7 +156,7 @@ func (t *Trie) GetState(node interface{}, key []int) interface{} {
 			return ""
 		}
 	} else if length == 17 {
-		return t.GetState(currentNode.Get(key[0]).AsRaw(), key[1:])
+		return t.GetState(currentNode.Get(key[0]).Raw(), key[1:])
 	}
 
 	// It shouldn't come this far
@@ -112,42 +164,64 @@ func (t *Trie) GetState(node interface{}, key []int) interface{} {
 	return ""
 }
 
-func (t *Trie) GetNode(node interface{}) *RlpValue {
-	n := Conv(node)
+func (t *Trie) GetNode(node interface{}) *Value {
+	n := NewValue(node)
 
 	//if n.Type() != reflect.String {
 	if !n.Get(0).IsNil() {
 		return n
 	}
 
-	str := n.AsString()
+	str := n.Str()
 	if len(str) == 0 {
 		return n
 	} else if len(str) < 32 {
-		d, _ := Decode([]byte(str), 0)
-		return Conv(d)
+		return t.decodeNode([]byte(str))
 	} else {
 		// Fetch the encoded node from the db
-		o, err := t.db.Get(n.AsBytes())
+		o, err := t.db.Get(n.Bytes())
 		if err != nil {
 			fmt.Println("Error InsertState", err)
-			return Conv("")
+			return NewValue("")
 		}
 
-		d, _ := Decode(o, 0)
-		return Conv(d)
+		return t.decodeNode(o)
 	}
 
 }
 
+// Nodes come from the db and may have been tampered with, so they're decoded
+// within the limits of DefaultDecodeOptions
+func (t *Trie) decodeNode(data []byte) *Value {
+	d, err := DefaultDecodeOptions.DecodeBytes(data)
+	if err != nil {
+		fmt.Println("Error decoding node", err)
+		return NewValue("")
+	}
+
+	return NewValue(d)
+}
+
+// Like GetNode but returns an error if the node isn't in the database
+func (t *Trie) resolveNode(node interface{}) (*Value, error) {
+	if _, ok := node.([]interface{}); ok {
+		return NewValue(node), nil
+	}
+
+	enc, err := t.db.Get(NewValue(node).Bytes())
+	if err != nil {
+		return nil, ErrTrieNodeNotStored
+	}
+
+	return DefaultDecodeOptions.DecodeValue(enc)
+}
+
 func (t *Trie) UpdateState(node interface{}, key []int, value string) interface{} {
 	if value != "" {
 		return t.InsertState(node, key, value)
-	} else {
-		// delete it
 	}
 
-	return ""
+	return t.DeleteState(node, key)
 }
 
 func (t *Trie) Put(node interface{}) interface{} {
@@ -174,6 +248,19 @@ func (t *Trie) Put(node interface{}) interface{} {
 	return node
 }
 
+// Returns the hash the node is known by. Nodes small enough to be inlined by
+// Put are hashed as well. Hashes may be held as []byte or string.
+func nodeHash(node interface{}) []byte {
+	switch node.(type) {
+	case []byte, string:
+		if hash := NewValue(node).Bytes(); len(hash) == 32 {
+			return hash
+		}
+	}
+
+	return Sha3Bin(Encode(node))
+}
+
 func EmptyStringSlice(l int) []interface{} {
 	slice := make([]interface{}, l)
 	for i := 0; i < l; i++ {
@@ -197,10 +284,10 @@ func (t *Trie) InsertState(node interface{}, key []int, value interface{}) inter
 
 	currentNode := t.GetNode(node)
 	// Check for "special" 2 slice type node
-	if currentNode.Length() == 2 {
+	if len(currentNode.Slice()) == 2 {
 		// Decode the key
-		k := CompactDecode(currentNode.Get(0).AsString())
-		v := currentNode.Get(1).AsRaw()
+		k := CompactDecode(currentNode.Get(0).Str())
+		v := currentNode.Get(1).Raw()
 
 		// Matching key pair (ie. there's already an object with this key)
 		if CompareIntSlice(k, key) {
@@ -239,13 +326,13 @@ func (t *Trie) InsertState(node interface{}, key []int, value interface{}) inter
 		newNode := EmptyStringSlice(17)
 
 		for i := 0; i < 17; i++ {
-			cpy := currentNode.Get(i).AsRaw()
+			cpy := currentNode.Get(i).Raw()
 			if cpy != nil {
 				newNode[i] = cpy
 			}
 		}
 
-		newNode[key[0]] = t.InsertState(currentNode.Get(key[0]).AsRaw(), key[1:], value)
+		newNode[key[0]] = t.InsertState(currentNode.Get(key[0]).Raw(), key[1:], value)
 
 		return t.Put(newNode)
 	}
@@ -255,8 +342,93 @@ func (t *Trie) InsertState(node interface{}, key []int, value interface{}) inter
 
 // Simple compare function which creates a rlp value out of the evaluated objects
 func (t *Trie) Cmp(trie *Trie) bool {
-	a := NewRlpValue(t.Root)
-	b := NewRlpValue(t.Root)
+	a := NewValue(t.Root)
+	b := NewValue(trie.Root)
+
+	return a.Equal(b)
+}
+
+// Whether the node is empty, either "" or an empty slot of a decoded node
+func isEmptyNode(node interface{}) bool {
+	n := NewValue(node)
+
+	return n.IsNil() || (len(n.Bytes()) == 0 && len(n.Slice()) == 0)
+}
+
+// Removes the key below node and returns the new node, "" if nothing is left.
+// Nodes left with a single child are collapsed, such that the result is the
+// node a trie which never held the key would have.
+func (t *Trie) DeleteState(node interface{}, key []int) interface{} {
+	if len(key) == 0 || isEmptyNode(node) {
+		return ""
+	}
+
+	currentNode := t.GetNode(node)
+	// Check for "special" 2 slice type node
+	if len(currentNode.Slice()) == 2 {
+		// Decode the key
+		k := CompactDecode(currentNode.Get(0).Str())
+		v := currentNode.Get(1).Raw()
+
+		if CompareIntSlice(k, key) {
+			// Matching leaf
+			return ""
+		} else if len(key) < len(k) || !CompareIntSlice(k, key[:len(k)]) {
+			// The key isn't in the trie
+			return node
+		}
+
+		child := t.DeleteState(v, key[len(k):])
+		if isEmptyNode(child) {
+			return ""
+		}
+
+		return t.extend(k, child)
+	}
+
+	// Copy the current node over to the new node and delete from the branch
+	// the key continues in
+	newNode := EmptyStringSlice(17)
+	for i := 0; i < 17; i++ {
+		cpy := currentNode.Get(i).Raw()
+		if cpy != nil {
+			newNode[i] = cpy
+		}
+	}
+	newNode[key[0]] = t.DeleteState(newNode[key[0]], key[1:])
+
+	// Collapse the branch if there's only a single child left
+	idx, children := -1, 0
+	for i, child := range newNode {
+		if !isEmptyNode(child) {
+			idx = i
+			children++
+		}
+	}
+
+	switch {
+	case children == 0:
+		return ""
+	case children > 1:
+		return t.Put(newNode)
+	case idx == 16:
+		// Only the value is left
+		return t.Put([]interface{}{CompactEncode([]int{16}), newNode[16]})
+	}
+
+	return t.extend([]int{idx}, newNode[idx])
+}
+
+// Creates the node for the key prefix followed by node. The prefix is merged
+// in to the key of node if node is a 2 slice type node itself.
+func (t *Trie) extend(prefix []int, node interface{}) interface{} {
+	childNode := t.GetNode(node)
+	if len(childNode.Slice()) == 2 {
+		k := CompactDecode(childNode.Get(0).Str())
+		key := append(append([]int{}, prefix...), k...)
+
+		return t.Put([]interface{}{CompactEncode(key), childNode.Get(1).Raw()})
+	}
 
-	return a.Cmp(b)
+	return t.Put([]interface{}{CompactEncode(prefix), node})
 }
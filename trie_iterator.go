@@ -0,0 +1,151 @@
+package ethutil
+
+import (
+	"bytes"
+)
+
+// Iterates over the key/value pairs of a trie in key order
+//
+//	it := trie.NewIterator()
+//	for it.Next() {
+//		fmt.Println(it.Key(), it.Value())
+//	}
+type TrieIterator struct {
+	trie  *Trie
+	stack []trieIterFrame
+	// Keys before this are skipped, see Seek
+	start []int
+
+	key, value string
+}
+
+// A node on the way to the current key/value pair
+type trieIterFrame struct {
+	node *Value
+	// The nibbles leading to the node
+	path []int
+	// The next slot of a 17 slice type node to visit, the value (slot 16)
+	// comes first as its key is the shortest
+	next int
+}
+
+// Creates an iterator positioned before the first key of the trie
+func (t *Trie) NewIterator() *TrieIterator {
+	it := &TrieIterator{trie: t}
+	it.Seek("")
+
+	return it
+}
+
+// Positions the iterator such that Next moves to the first key which isn't
+// less than start
+func (it *TrieIterator) Seek(start string) {
+	key := CompactHexDecode(start)
+
+	it.start = key[:len(key)-1]
+	it.stack = it.stack[:0]
+	it.key, it.value = "", ""
+	it.push(it.trie.Root, nil)
+}
+
+// Moves to the next key/value pair, returns false when there are no more
+func (it *TrieIterator) Next() bool {
+	for len(it.stack) > 0 {
+		top := &it.stack[len(it.stack)-1]
+		node := top.node.Slice()
+
+		switch len(node) {
+		case 2:
+			it.stack = it.stack[:len(it.stack)-1]
+
+			k := CompactDecode(NewValue(node[0]).Str())
+			path := joinNibbles(top.path, k)
+			if k[len(k)-1] != 16 {
+				it.push(node[1], path)
+			} else if it.yield(path[:len(path)-1], node[1]) {
+				return true
+			}
+		case 17:
+			if top.next > 16 {
+				it.stack = it.stack[:len(it.stack)-1]
+
+				continue
+			}
+
+			slot := (top.next + 16) % 17
+			top.next++
+			if slot == 16 {
+				if !isEmptyNode(node[16]) && it.yield(top.path, node[16]) {
+					return true
+				}
+			} else {
+				it.push(node[slot], joinNibbles(top.path, []int{slot}))
+			}
+		default:
+			it.stack = it.stack[:len(it.stack)-1]
+		}
+	}
+
+	it.key, it.value = "", ""
+
+	return false
+}
+
+// The key of the current pair
+func (it *TrieIterator) Key() string {
+	return it.key
+}
+
+// The value of the current pair
+func (it *TrieIterator) Value() string {
+	return it.value
+}
+
+// Resolves and pushes the node unless all keys below it come before start
+func (it *TrieIterator) push(node interface{}, path []int) {
+	if isEmptyNode(node) {
+		return
+	}
+
+	n := len(path)
+	if n > len(it.start) {
+		n = len(it.start)
+	}
+	if compareNibbles(path[:n], it.start[:n]) < 0 {
+		return
+	}
+
+	it.stack = append(it.stack, trieIterFrame{node: it.trie.GetNode(node), path: path})
+}
+
+// Makes the pair current unless its key comes before start
+func (it *TrieIterator) yield(path []int, value interface{}) bool {
+	if compareNibbles(path, it.start) < 0 {
+		return false
+	}
+
+	var key bytes.Buffer
+	for i := 0; i+1 < len(path); i += 2 {
+		key.WriteByte(byte(path[i]<<4 | path[i+1]))
+	}
+	it.key, it.value = key.String(), NewValue(value).Str()
+
+	return true
+}
+
+func joinNibbles(a, b []int) []int {
+	nibbles := make([]int, 0, len(a)+len(b))
+
+	return append(append(nibbles, a...), b...)
+}
+
+// Compares nibble slices the way the keys they make up compare
+func compareNibbles(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+
+	return len(a) - len(b)
+}
@@ -0,0 +1,119 @@
+package ethutil
+
+import (
+	"errors"
+)
+
+var (
+	ErrTrieInvalidNode   = errors.New("trie: invalid node")
+	ErrProofMissingNode  = errors.New("trie: proof is missing a node")
+	ErrTrieNodeNotStored = errors.New("trie: node not in database")
+)
+
+// Returns the RLP encoded nodes on the path GetState walks for the key, root
+// first. Nodes small enough to be inlined in their parent aren't listed
+// separately. The proof also shows the absence of keys which aren't in the
+// trie.
+func (t *Trie) Prove(key string) ([][]byte, error) {
+	if isEmptyNode(t.Root) {
+		return [][]byte{Encode("")}, nil
+	}
+
+	var proof [][]byte
+	resolve := func(node interface{}) (*Value, error) {
+		if _, ok := node.([]interface{}); ok {
+			// Only the root needs an entry, other inlined nodes are
+			// part of their parent's encoding
+			if len(proof) == 0 {
+				proof = append(proof, Encode(node))
+			}
+
+			return NewValue(node), nil
+		}
+
+		enc, err := t.db.Get(NewValue(node).Bytes())
+		if err != nil {
+			return nil, ErrTrieNodeNotStored
+		}
+		proof = append(proof, enc)
+
+		return DefaultDecodeOptions.DecodeValue(enc)
+	}
+
+	if _, err := lookupNode(t.Root, CompactHexDecode(key), resolve); err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// Checks the proof against the root hash and returns the value of the key, ""
+// if the proof shows the key isn't in the trie. Each node is looked up by the
+// Sha3Bin hash of its encoding, so nodes which don't belong to the trie are
+// never used.
+func VerifyProof(rootHash []byte, key string, proof [][]byte) (string, error) {
+	nodes := make(map[string][]byte)
+	for _, enc := range proof {
+		nodes[string(Sha3Bin(enc))] = enc
+	}
+
+	resolve := func(node interface{}) (*Value, error) {
+		if _, ok := node.([]interface{}); ok {
+			return NewValue(node), nil
+		}
+
+		enc, ok := nodes[string(NewValue(node).Bytes())]
+		if !ok {
+			return nil, ErrProofMissingNode
+		}
+
+		return DefaultDecodeOptions.DecodeValue(enc)
+	}
+
+	value, err := lookupNode(rootHash, CompactHexDecode(key), resolve)
+	if err != nil {
+		return "", err
+	}
+
+	return NewValue(value).Str(), nil
+}
+
+// Follows the key down from node like GetState does, resolving nodes with
+// resolve. Returns the value or "" if the key isn't there.
+func lookupNode(node interface{}, key []int, resolve func(interface{}) (*Value, error)) (interface{}, error) {
+	for len(key) > 0 {
+		if isEmptyNode(node) {
+			return "", nil
+		}
+
+		n, err := resolve(node)
+		if err != nil {
+			return nil, err
+		}
+
+		switch len(n.Slice()) {
+		case 0:
+			if !isEmptyNode(n.Val) {
+				return nil, ErrTrieInvalidNode
+			}
+
+			return "", nil
+		case 2:
+			if len(n.Get(0).Bytes()) == 0 {
+				return nil, ErrTrieInvalidNode
+			}
+
+			k := CompactDecode(n.Get(0).Str())
+			if len(key) < len(k) || !CompareIntSlice(k, key[:len(k)]) {
+				return "", nil
+			}
+			node, key = n.Get(1).Raw(), key[len(k):]
+		case 17:
+			node, key = n.Get(key[0]).Raw(), key[1:]
+		default:
+			return nil, ErrTrieInvalidNode
+		}
+	}
+
+	return node, nil
+}
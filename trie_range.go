@@ -0,0 +1,188 @@
+package ethutil
+
+import (
+	"errors"
+)
+
+var (
+	ErrRangeProofKeys     = errors.New("trie: keys aren't sorted or outside of the range")
+	ErrRangeProofMismatch = errors.New("trie: range doesn't match the root")
+)
+
+// Returns the key/value pairs with keys between first and last, both
+// included, in key order, along with the proof VerifyRangeProof needs to check
+// they're all the trie holds in that range. The proof consists of the proofs
+// of first and last.
+func (t *Trie) ProveRange(first, last string) (keys, values []string, proof [][]byte, err error) {
+	seen := make(map[string]bool)
+	for _, key := range []string{first, last} {
+		edge, err := t.Prove(key)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, enc := range edge {
+			if !seen[string(enc)] {
+				seen[string(enc)] = true
+				proof = append(proof, enc)
+			}
+		}
+	}
+
+	it := t.NewIterator()
+	for it.Seek(first); it.Next() && it.Key() <= last; {
+		keys = append(keys, it.Key())
+		values = append(values, it.Value())
+	}
+
+	return keys, values, proof, nil
+}
+
+// Checks that keys and values, sorted by key, are exactly the contents of the
+// trie with the given root hash between first and last. The trie is rebuilt
+// from the nodes in the proof, everything between first and last is cleared
+// and the pairs are inserted again. The result must hash to the root.
+func VerifyRangeProof(rootHash []byte, first, last string, keys, values []string, proof [][]byte) error {
+	if first > last || len(keys) != len(values) {
+		return ErrRangeProofKeys
+	}
+	for i, key := range keys {
+		if key < first || key > last || (i > 0 && key <= keys[i-1]) || values[i] == "" {
+			return ErrRangeProofKeys
+		}
+	}
+
+	db := newMemDatabase()
+	for _, enc := range proof {
+		db.Put(Sha3Bin(enc), enc)
+	}
+
+	lo, hi := CompactHexDecode(first), CompactHexDecode(last)
+	r := &rangeClearer{trie: NewTrie(db, rootHash), lo: lo[:len(lo)-1], hi: hi[:len(hi)-1]}
+
+	root, _, err := r.clear(rootHash, nil)
+	if err != nil {
+		return err
+	}
+
+	trie := NewTrie(db, root)
+	for i, key := range keys {
+		trie.Update(key, values[i])
+	}
+
+	if string(trie.Hash()) != string(rootHash) {
+		return ErrRangeProofMismatch
+	}
+
+	return nil
+}
+
+// Removes the keys between lo and hi from a partial trie
+type rangeClearer struct {
+	trie   *Trie
+	lo, hi []int
+}
+
+// Whether the key is between lo and hi
+func (r *rangeClearer) contains(key []int) bool {
+	return compareNibbles(key, r.lo) >= 0 && compareNibbles(key, r.hi) <= 0
+}
+
+// Whether all keys starting with path are below lo or above hi
+func (r *rangeClearer) outside(path []int) bool {
+	return prefixBelow(path, r.lo) || compareNibbles(path, r.hi) > 0
+}
+
+// Whether all keys starting with path are between lo and hi
+func (r *rangeClearer) inside(path []int) bool {
+	return compareNibbles(path, r.lo) >= 0 && prefixBelow(path, r.hi)
+}
+
+// Whether all keys starting with path are below key
+func prefixBelow(path, key []int) bool {
+	n := len(path)
+	if n > len(key) {
+		n = len(key)
+	}
+
+	return compareNibbles(path[:n], key[:n]) < 0
+}
+
+// Returns node with the keys between lo and hi removed and whether anything
+// changed. Only nodes on the paths to lo and hi are resolved, the subtrees in
+// between are dropped without looking at them. Branches aren't collapsed, the
+// keys inserted afterwards fill them again.
+func (r *rangeClearer) clear(node interface{}, path []int) (interface{}, bool, error) {
+	if isEmptyNode(node) || r.outside(path) {
+		return node, false, nil
+	} else if r.inside(path) {
+		return "", true, nil
+	}
+
+	n, err := r.trie.resolveNode(node)
+	if err != nil {
+		return nil, false, ErrProofMissingNode
+	}
+
+	switch len(n.Slice()) {
+	case 0:
+		return node, false, nil
+	case 2:
+		if len(n.Get(0).Bytes()) == 0 {
+			return nil, false, ErrTrieInvalidNode
+		}
+		k := CompactDecode(n.Get(0).Str())
+		if len(k) == 0 {
+			return nil, false, ErrTrieInvalidNode
+		}
+
+		full := joinNibbles(path, k)
+		if k[len(k)-1] == 16 {
+			if r.contains(full[:len(full)-1]) {
+				return "", true, nil
+			}
+
+			return node, false, nil
+		}
+
+		child, changed, err := r.clear(n.Get(1).Raw(), full)
+		if err != nil || !changed {
+			return node, false, err
+		} else if isEmptyNode(child) {
+			return "", true, nil
+		}
+
+		return r.trie.Put([]interface{}{CompactEncode(k), child}), true, nil
+	case 17:
+		newNode := EmptyStringSlice(17)
+		changed, empty := false, true
+		for i := 0; i < 17; i++ {
+			slot := n.Get(i).Raw()
+			if i == 16 {
+				if !isEmptyNode(slot) && r.contains(path) {
+					slot, changed = "", true
+				}
+			} else {
+				cleared, ok, err := r.clear(slot, joinNibbles(path, []int{i}))
+				if err != nil {
+					return nil, false, err
+				}
+				slot, changed = cleared, changed || ok
+			}
+
+			if !isEmptyNode(slot) {
+				newNode[i], empty = slot, false
+			}
+		}
+
+		if !changed {
+			return node, false, nil
+		} else if empty {
+			return "", true, nil
+		}
+
+		return r.trie.Put(newNode), true, nil
+	}
+
+	return nil, false, ErrTrieInvalidNode
+}
@@ -1,11 +1,320 @@
 package ethutil
 
 import (
+	"bytes"
 	_ "encoding/hex"
-	_ "fmt"
-	_ "testing"
+	"fmt"
+	"sort"
+	"testing"
 )
 
+var trieTestPairs = [][2]string{
+	{"do", "verb"},
+	{"dog", "puppy"},
+	{"doge", "coin"},
+	{"horse", "stallion"},
+	{"dogglesworth", "cat"},
+	{"doe", "reindeer"},
+	{"a", "a value long enough to make the leaf node hashed"},
+	{"ab", "x"},
+	{"\x01", "y"},
+}
+
+func newTestTrie(pairs [][2]string, skip string) *Trie {
+	trie := NewTrie(newMemDatabase(), "")
+	for _, pair := range pairs {
+		if pair[0] != skip {
+			trie.Update(pair[0], pair[1])
+		}
+	}
+
+	return trie
+}
+
+func TestTrieDelete(t *testing.T) {
+	for _, pair := range trieTestPairs {
+		trie := newTestTrie(trieTestPairs, "")
+		trie.Delete(pair[0])
+
+		if exp := newTestTrie(trieTestPairs, pair[0]); !trie.Cmp(exp) {
+			t.Errorf("%q: root %x differs from %x", pair[0], Encode(trie.Root), Encode(exp.Root))
+		}
+
+		if val := trie.Get(pair[0]); val != "" {
+			t.Errorf("%q: still in the trie as %q", pair[0], val)
+		}
+		for _, other := range trieTestPairs {
+			if other[0] != pair[0] && trie.Get(other[0]) != other[1] {
+				t.Errorf("%q: deleting it lost %q", pair[0], other[0])
+			}
+		}
+	}
+}
+
+func TestTrieDeleteAll(t *testing.T) {
+	trie := newTestTrie(trieTestPairs, "")
+	for _, key := range []string{"missing", "dogg", "d", ""} {
+		trie.Delete(key)
+		if !trie.Cmp(newTestTrie(trieTestPairs, "")) {
+			t.Errorf("Deleting missing key %q changed the trie", key)
+		}
+	}
+
+	for i, pair := range trieTestPairs {
+		trie.Update(pair[0], "")
+
+		if exp := newTestTrie(trieTestPairs[i+1:], ""); !trie.Cmp(exp) {
+			t.Errorf("%q: root %x differs from %x", pair[0], Encode(trie.Root), Encode(exp.Root))
+		}
+	}
+
+	if !isEmptyNode(trie.Root) {
+		t.Errorf("Expected an empty trie, got %x", Encode(trie.Root))
+	}
+}
+
+func TestTrieIterator(t *testing.T) {
+	trie := newTestTrie(trieTestPairs, "")
+
+	var keys []string
+	for _, pair := range trieTestPairs {
+		keys = append(keys, pair[0])
+	}
+	sort.Strings(keys)
+
+	var res []string
+	for it := trie.NewIterator(); it.Next(); {
+		if it.Value() != trie.Get(it.Key()) {
+			t.Errorf("%q: iterator returned %q, Get %q", it.Key(), it.Value(), trie.Get(it.Key()))
+		}
+		res = append(res, it.Key())
+	}
+
+	if fmt.Sprintf("%q", res) != fmt.Sprintf("%q", keys) {
+		t.Errorf("Expected %q, got %q", keys, res)
+	}
+
+	if NewTrie(newMemDatabase(), "").NewIterator().Next() {
+		t.Error("Expected no keys in an empty trie")
+	}
+}
+
+func TestTrieIteratorSeek(t *testing.T) {
+	it := newTestTrie(trieTestPairs, "").NewIterator()
+
+	tests := []struct{ start, first string }{
+		{"", "\x01"},
+		{"dog", "dog"},
+		{"dogf", "dogglesworth"},
+		{"d", "do"},
+		{"doe\x00", "dog"},
+		{"e", "horse"},
+	}
+
+	for _, test := range tests {
+		it.Seek(test.start)
+		if !it.Next() || it.Key() != test.first {
+			t.Errorf("Seek(%q): expected %q, got %q", test.start, test.first, it.Key())
+		}
+	}
+
+	it.Seek("z")
+	if it.Next() {
+		t.Errorf("Seek past the end returned %q", it.Key())
+	}
+
+	// Paging through the trie
+	var res []string
+	for start := ""; ; {
+		it.Seek(start)
+		if !it.Next() {
+			break
+		}
+		res = append(res, it.Key())
+		start = it.Key() + "\x00"
+	}
+	if len(res) != len(trieTestPairs) {
+		t.Errorf("Expected %d keys paging, got %q", len(trieTestPairs), res)
+	}
+}
+
+func TestTrieProof(t *testing.T) {
+	trie := newTestTrie(trieTestPairs, "")
+	root := trie.Hash()
+
+	for _, pair := range trieTestPairs {
+		proof, err := trie.Prove(pair[0])
+		if err != nil {
+			t.Fatalf("%q: %v", pair[0], err)
+		}
+
+		if val, err := VerifyProof(root, pair[0], proof); err != nil || val != pair[1] {
+			t.Errorf("%q: expected %q, got %q (%v)", pair[0], pair[1], val, err)
+		}
+
+		if _, err := VerifyProof(root, pair[0], proof[1:]); err != ErrProofMissingNode {
+			t.Errorf("%q: expected %v without the root, got %v", pair[0], ErrProofMissingNode, err)
+		}
+	}
+
+	// Absence
+	for _, key := range []string{"dogg", "d", "cat", "horses", ""} {
+		proof, err := trie.Prove(key)
+		if err != nil {
+			t.Fatalf("%q: %v", key, err)
+		}
+
+		if val, err := VerifyProof(root, key, proof); err != nil || val != "" {
+			t.Errorf("%q: expected absence, got %q (%v)", key, val, err)
+		}
+	}
+
+	// Tampering with a node changes its hash
+	proof, _ := trie.Prove("a")
+	last := proof[len(proof)-1]
+	proof[len(proof)-1] = bytes.Replace(last, []byte("hashed"), []byte("HASHED"), 1)
+	if bytes.Equal(last, proof[len(proof)-1]) {
+		t.Fatal("Expected the value in the last node")
+	}
+	if val, err := VerifyProof(root, "a", proof); err != ErrProofMissingNode {
+		t.Errorf("Expected %v for a tampered proof, got %q (%v)", ErrProofMissingNode, val, err)
+	}
+}
+
+func TestTrieProofSmall(t *testing.T) {
+	// Both an empty trie and one with an inlined root
+	for _, pairs := range [][][2]string{nil, {{"do", "verb"}}} {
+		trie := newTestTrie(pairs, "")
+
+		for _, key := range []string{"do", "dog"} {
+			proof, err := trie.Prove(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exp := ""
+			if len(pairs) > 0 && key == "do" {
+				exp = "verb"
+			}
+			if val, err := VerifyProof(trie.Hash(), key, proof); err != nil || val != exp {
+				t.Errorf("%q: expected %q, got %q (%v)", key, exp, val, err)
+			}
+		}
+	}
+}
+
+func TestTrieRangeProof(t *testing.T) {
+	trie := newTestTrie(trieTestPairs, "")
+	root := trie.Hash()
+
+	bounds := []string{"", "\x01", "a", "b", "do", "dog", "dogf", "dogglesworth", "e", "horse", "z"}
+	for i, first := range bounds {
+		for _, last := range bounds[i:] {
+			keys, values, proof, err := trie.ProveRange(first, last)
+			if err != nil {
+				t.Fatalf("[%q, %q]: %v", first, last, err)
+			}
+
+			if err := VerifyRangeProof(root, first, last, keys, values, proof); err != nil {
+				t.Errorf("[%q, %q] %q: %v", first, last, keys, err)
+			}
+
+			// Leaving out any of the keys must be noticed
+			for j := range keys {
+				k := append(append([]string{}, keys[:j]...), keys[j+1:]...)
+				v := append(append([]string{}, values[:j]...), values[j+1:]...)
+				if err := VerifyRangeProof(root, first, last, k, v, proof); err != ErrRangeProofMismatch {
+					t.Errorf("[%q, %q] without %q: expected %v, got %v", first, last, keys[j], ErrRangeProofMismatch, err)
+				}
+			}
+		}
+	}
+}
+
+func TestTrieRangeProofTampered(t *testing.T) {
+	trie := newTestTrie(trieTestPairs, "")
+	root := trie.Hash()
+
+	keys, values, proof, _ := trie.ProveRange("b", "dogf")
+	if len(keys) != 4 {
+		t.Fatalf("Expected 4 keys, got %q", keys)
+	}
+
+	values[1] = "changed"
+	if err := VerifyRangeProof(root, "b", "dogf", keys, values, proof); err != ErrRangeProofMismatch {
+		t.Errorf("Changed value: expected %v, got %v", ErrRangeProofMismatch, err)
+	}
+
+	keys, values, _, _ = trie.ProveRange("b", "dogf")
+	extra := append([]string{"cat"}, keys...)
+	if err := VerifyRangeProof(root, "b", "dogf", extra, append([]string{"meow"}, values...), proof); err != ErrRangeProofMismatch {
+		t.Errorf("Added key: expected %v, got %v", ErrRangeProofMismatch, err)
+	}
+
+	if err := VerifyRangeProof(root, "b", "dogf", keys, values, proof[1:]); err != ErrProofMissingNode {
+		t.Errorf("Missing root: expected %v, got %v", ErrProofMissingNode, err)
+	}
+
+	if err := VerifyRangeProof(root, "b", "dog", keys, values, proof); err != ErrRangeProofKeys {
+		t.Errorf("Key out of range: expected %v, got %v", ErrRangeProofKeys, err)
+	}
+	if err := VerifyRangeProof(root, "b", "dogf", []string{keys[1], keys[0]}, values[:2], proof); err != ErrRangeProofKeys {
+		t.Errorf("Unsorted keys: expected %v, got %v", ErrRangeProofKeys, err)
+	}
+}
+
+func TestTrieHash(t *testing.T) {
+	if hash := NewTrie(newMemDatabase(), "").Hash(); !bytes.Equal(hash, Sha3Bin([]byte{0x80})) {
+		t.Errorf("Unexpected empty trie hash %x", hash)
+	}
+
+	for _, pairs := range [][][2]string{nil, {{"do", "verb"}}, trieTestPairs} {
+		trie := newTestTrie(pairs, "")
+		hash := trie.Hash()
+		if len(hash) != 32 {
+			t.Fatalf("Expected a 32 byte hash, got %x", hash)
+		}
+
+		if commit := trie.Commit(); !bytes.Equal(commit, hash) {
+			t.Errorf("Commit returned %x, expected %x", commit, hash)
+		}
+
+		opened := NewTrieFromHash(trie.db, hash)
+		if !bytes.Equal(opened.Hash(), hash) {
+			t.Errorf("Opened trie hashes to %x, expected %x", opened.Hash(), hash)
+		}
+		for _, pair := range pairs {
+			if val := opened.Get(pair[0]); val != pair[1] {
+				t.Errorf("%q: expected %q, got %q", pair[0], pair[1], val)
+			}
+		}
+
+		// The opened trie can be changed like the original
+		opened.Update("new", "key")
+		trie.Update("new", "key")
+		if !bytes.Equal(opened.Hash(), trie.Hash()) {
+			t.Errorf("Updated trie hashes to %x, expected %x", opened.Hash(), trie.Hash())
+		}
+	}
+}
+
+func TestTrieHashStringRoot(t *testing.T) {
+	trie := newTestTrie(trieTestPairs, "")
+	hash := trie.Commit()
+
+	// NewTrie also accepts the root hash as a string
+	opened := NewTrie(trie.db, string(hash))
+	if !bytes.Equal(opened.Hash(), hash) {
+		t.Errorf("Hash returned %x, expected %x", opened.Hash(), hash)
+	}
+	if commit := opened.Commit(); !bytes.Equal(commit, hash) {
+		t.Errorf("Commit returned %x, expected %x", commit, hash)
+	}
+	if val := opened.Get(trieTestPairs[0][0]); val != trieTestPairs[0][1] {
+		t.Errorf("Expected %q, got %q", trieTestPairs[0][1], val)
+	}
+}
+
 /*
 func TestTriePut(t *testing.T) {
 	db, err := NewMemDatabase()
@@ -9,16 +9,34 @@ import (
 // Data values are returned by the rlp decoder. The data values represents
 // one item within the rlp data structure. It's responsible for all the casting
 // It always returns something valid
+//
+// Lists may hold other *Value's (as AppendList does), those are encoded and
+// accessed like the values they hold.
 type Value struct {
 	Val  interface{}
 	kind reflect.Value
+	// Indices leading to this value, used in error messages
+	path []int
 }
 
 func (val *Value) String() string {
 	return fmt.Sprintf("%x", val.Val)
 }
 
+// Creates a new value holding val. A *Value or *RlpValue is unwrapped so
+// values never hold another value directly.
 func NewValue(val interface{}) *Value {
+	switch t := val.(type) {
+	case *Value:
+		if t != nil {
+			return NewValue(t.Val)
+		}
+	case *RlpValue:
+		if t != nil {
+			return NewValue(t.Value)
+		}
+	}
+
 	return &Value{Val: val}
 }
 
@@ -46,53 +64,94 @@ func (val *Value) Raw() interface{} {
 	return val.Val
 }
 
+// Integers are held either as Go integers or, as the decoder returns them, as
+// big endian byte strings. The accessors below handle both.
 func (val *Value) Uint() uint64 {
-	if Val, ok := val.Val.(uint8); ok {
-		return uint64(Val)
-	} else if Val, ok := val.Val.(uint16); ok {
-		return uint64(Val)
-	} else if Val, ok := val.Val.(uint32); ok {
-		return uint64(Val)
-	} else if Val, ok := val.Val.(uint64); ok {
-		return Val
-	}
+	num, _ := val.TryUint()
 
-	return 0
+	return num
+}
+
+func (val *Value) Int() int64 {
+	num, _ := val.TryInt()
+
+	return num
 }
 
 func (val *Value) Byte() byte {
-	if Val, ok := val.Val.(byte); ok {
-		return Val
-	}
+	b, _ := val.TryByte()
 
-	return 0x0
+	return b
 }
 
 func (val *Value) BigInt() *big.Int {
-	if a, ok := val.Val.([]byte); ok {
-		b := new(big.Int).SetBytes(a)
+	num, err := val.bigInt()
+	if err != nil {
+		return big.NewInt(0)
+	}
+
+	return num
+}
 
-		return b
-	} else {
-		return big.NewInt(int64(val.Uint()))
+// Returns the value as a new big.Int
+func (val *Value) bigInt() (*big.Int, error) {
+	switch t := val.Val.(type) {
+	case []byte:
+		return new(big.Int).SetBytes(t), nil
+	case string:
+		return new(big.Int).SetBytes([]byte(t)), nil
+	case *big.Int:
+		return new(big.Int).Set(t), nil
+	case big.Int:
+		return new(big.Int).Set(&t), nil
+	case uint:
+		return new(big.Int).SetUint64(uint64(t)), nil
+	case uint8:
+		return new(big.Int).SetUint64(uint64(t)), nil
+	case uint16:
+		return new(big.Int).SetUint64(uint64(t)), nil
+	case uint32:
+		return new(big.Int).SetUint64(uint64(t)), nil
+	case uint64:
+		return new(big.Int).SetUint64(t), nil
+	case int:
+		return big.NewInt(int64(t)), nil
+	case int8:
+		return big.NewInt(int64(t)), nil
+	case int16:
+		return big.NewInt(int64(t)), nil
+	case int32:
+		return big.NewInt(int64(t)), nil
+	case int64:
+		return big.NewInt(t), nil
 	}
 
-	return big.NewInt(0)
+	return nil, val.errorf(ErrValueNotInteger)
 }
 
+// Single bytes, as returned by the decoder, are treated as a one byte string
 func (val *Value) Str() string {
-	if a, ok := val.Val.([]byte); ok {
-		return string(a)
-	} else if a, ok := val.Val.(string); ok {
-		return a
+	switch t := val.Val.(type) {
+	case []byte:
+		return string(t)
+	case string:
+		return t
+	case byte:
+		return string([]byte{t})
 	}
 
 	return ""
 }
 
+// Single bytes, as returned by the decoder, are treated as a one byte string
 func (val *Value) Bytes() []byte {
-	if a, ok := val.Val.([]byte); ok {
-		return a
+	switch t := val.Val.(type) {
+	case []byte:
+		return t
+	case string:
+		return []byte(t)
+	case byte:
+		return []byte{t}
 	}
 
 	return make([]byte, 0)
@@ -128,14 +187,10 @@ func (val *Value) SliceFromTo(from, to int) *Value {
 func (val *Value) Get(idx int) *Value {
 	if d, ok := val.Val.([]interface{}); ok {
 		// Guard for oob
-		if len(d) <= idx {
+		if idx < 0 || len(d) <= idx {
 			return NewValue(nil)
 		}
 
-		if idx < 0 {
-			panic("negative idx for Rlp Get")
-		}
-
 		return NewValue(d[idx])
 	}
 
@@ -143,10 +198,13 @@ func (val *Value) Get(idx int) *Value {
 	return NewValue(nil)
 }
 
+// Compares the Go values held, see Equal for comparing by encoding
 func (val *Value) Cmp(o *Value) bool {
 	return reflect.DeepEqual(val.Val, o.Val)
 }
 
+// Returns the RLP encoding of the value, nil if it can't be encoded. See
+// Encode.
 func (val *Value) Encode() []byte {
 	return Encode(val.Val)
 }
@@ -160,6 +218,11 @@ func NewValueFromBytes(rlpData []byte) *Value {
 	return NewValue(nil)
 }
 
+// Like NewValueFromBytes but returns an error for malformed data
+func DecodeValue(rlpData []byte) (*Value, error) {
+	return DefaultDecodeOptions.DecodeValue(rlpData)
+}
+
 // Value setters
 func NewSliceValue(s interface{}) *Value {
 	list := EmptyValue()
@@ -195,3 +258,116 @@ func (val *Value) Append(v interface{}) *Value {
 
 	return val
 }
+
+// A value returned by Get shares its list with the parent. Set changes the
+// list in place, so it shows up in both. Insert, Remove and Truncate give the
+// value a new list and leave the parent as it was, store the list back with
+// Set:
+//
+//	inner := val.Get(2)
+//	inner.Remove(0)
+//	val.Set(2, inner)
+
+// Replaces the idx'th element of the list
+func (val *Value) Set(idx int, v interface{}) error {
+	d, err := val.listIndex(idx, 0)
+	if err != nil {
+		return err
+	}
+	d[idx] = v
+
+	return nil
+}
+
+// Inserts v before the idx'th element. An idx of Len() appends v.
+func (val *Value) Insert(idx int, v interface{}) error {
+	d, err := val.listIndex(idx, 1)
+	if err != nil {
+		return err
+	}
+
+	list := make([]interface{}, len(d)+1)
+	copy(list, d[:idx])
+	list[idx] = v
+	copy(list[idx+1:], d[idx:])
+	val.Val = list
+
+	return nil
+}
+
+// Removes the idx'th element of the list
+func (val *Value) Remove(idx int) error {
+	d, err := val.listIndex(idx, 0)
+	if err != nil {
+		return err
+	}
+	list := make([]interface{}, len(d)-1)
+	copy(list, d[:idx])
+	copy(list[idx:], d[idx+1:])
+	val.Val = list
+
+	return nil
+}
+
+// Shortens the list to its first n elements
+func (val *Value) Truncate(n int) error {
+	d, err := val.listIndex(n, 1)
+	if err != nil {
+		return err
+	}
+	list := make([]interface{}, n)
+	copy(list, d)
+	val.Val = list
+
+	return nil
+}
+
+// Returns the list if idx is within it, allowing for idx to be up to extra
+// past its last element
+func (val *Value) listIndex(idx, extra int) ([]interface{}, error) {
+	d, err := val.TrySlice()
+	if err != nil {
+		return nil, err
+	}
+
+	if idx < 0 || idx >= len(d)+extra {
+		return nil, &ValueError{Err: ErrValueIndex, Path: val.child(idx, nil).path, Got: fmt.Sprintf("list of %d", len(d))}
+	}
+
+	return d, nil
+}
+
+// Returns a copy of the value which shares no lists or byte slices with it.
+// Nested values are replaced by copies of the data they hold.
+func (val *Value) DeepCopy() *Value {
+	cpy := NewValue(deepCopy(val.Val))
+	cpy.path = val.path
+
+	return cpy
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(t))
+		for i, elem := range t {
+			list[i] = deepCopy(elem)
+		}
+
+		return list
+	case []byte:
+		return append([]byte{}, t...)
+	case *big.Int:
+		return new(big.Int).Set(t)
+	case *Value:
+		if t != nil {
+			return deepCopy(t.Val)
+		}
+	case *RlpValue:
+		if t != nil {
+			return deepCopy(t.Value)
+		}
+	}
+
+	return v
+}
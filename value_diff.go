@@ -0,0 +1,119 @@
+package ethutil
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// Reports whether both values have the same RLP encoding, e.g. uint8(1) and
+// []byte{1} are equal. Unlike Cmp nested values and Go types don't matter.
+func (val *Value) Equal(o *Value) bool {
+	a, errA := EncodeToBytes(val.Val)
+	b, errB := EncodeToBytes(o.Val)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(val.Val, o.Val)
+	}
+
+	return bytes.Equal(a, b)
+}
+
+type DiffKind int
+
+const (
+	DiffAdded DiffKind = iota
+	DiffRemoved
+	DiffChanged
+)
+
+func (kind DiffKind) String() string {
+	switch kind {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	}
+
+	return "changed"
+}
+
+// A Difference between two values found by Diff. A is nil for added and B for
+// removed elements.
+type Difference struct {
+	Path []int
+	Kind DiffKind
+	A, B *Value
+}
+
+func (diff Difference) String() string {
+	switch diff.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("%s: added %s", formatPath(diff.Path), diffJson(diff.B))
+	case DiffRemoved:
+		return fmt.Sprintf("%s: removed %s", formatPath(diff.Path), diffJson(diff.A))
+	}
+
+	return fmt.Sprintf("%s: changed %s -> %s", formatPath(diff.Path), diffJson(diff.A), diffJson(diff.B))
+}
+
+func diffJson(val *Value) string {
+	b, err := val.JSON(true)
+	if err != nil {
+		return fmt.Sprintf("%v", val.Val)
+	}
+
+	return string(b)
+}
+
+// Lists where b differs from a. Like Equal values are compared by their
+// encoding. Lists are compared element by element: elements past the end of
+// the shorter list are reported as added or removed, others as changed (or
+// the differences within them if both are lists). Returns nil if a and b are
+// equal.
+func Diff(a, b *Value) []Difference {
+	return diffValues(nil, normalize(a), normalize(b), nil)
+}
+
+// Returns a value holding what decoding the encoding of val gives
+func normalize(val *Value) *Value {
+	enc, err := EncodeToBytes(val.Val)
+	if err != nil {
+		return val
+	}
+
+	dec, err := DecodeBytes(enc)
+	if err != nil {
+		return val
+	}
+
+	return NewValue(dec)
+}
+
+func diffValues(path []int, a, b *Value, diffs []Difference) []Difference {
+	listA, okA := a.Val.([]interface{})
+	listB, okB := b.Val.([]interface{})
+	if !okA || !okB {
+		if !a.Equal(b) {
+			diffs = append(diffs, Difference{path, DiffChanged, a, b})
+		}
+
+		return diffs
+	}
+
+	for i := 0; i < len(listA) || i < len(listB); i++ {
+		elem := make([]int, len(path)+1)
+		copy(elem, path)
+		elem[len(path)] = i
+
+		switch {
+		case i >= len(listA):
+			diffs = append(diffs, Difference{elem, DiffAdded, nil, NewValue(listB[i])})
+		case i >= len(listB):
+			diffs = append(diffs, Difference{elem, DiffRemoved, NewValue(listA[i]), nil})
+		default:
+			diffs = diffValues(elem, NewValue(listA[i]), NewValue(listB[i]), diffs)
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,55 @@
+package ethutil
+
+import (
+	"testing"
+)
+
+func TestValueEqual(t *testing.T) {
+	a := NewValue([]interface{}{uint8(1), "dog", uint(1024)})
+	b := NewValueFromBytes(a.Encode())
+	b.AppendList()
+	b.Truncate(3)
+
+	if !a.Equal(b) || a.Cmp(b) {
+		t.Errorf("Expected %v and %v to be equal by encoding only", a.Val, b.Val)
+	}
+
+	if a.Equal(NewValue([]interface{}{1, "dog"})) {
+		t.Error("Expected values of different length to differ")
+	}
+}
+
+func TestValueDiff(t *testing.T) {
+	a := NewValue([]interface{}{1, "dog", []interface{}{"cat", "horse"}, "gone"})
+	b := NewValue([]interface{}{[]byte{1}, "dog", []interface{}{"bat"}})
+
+	if diffs := Diff(a, NewValueFromBytes(a.Encode())); diffs != nil {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+
+	exp := []string{
+		`[2][0]: changed "cat" -> "bat"`,
+		`[2][1]: removed "horse"`,
+		`[3]: removed "gone"`,
+	}
+
+	diffs := Diff(a, b)
+	if len(diffs) != len(exp) {
+		t.Fatalf("Expected %d differences, got %v", len(exp), diffs)
+	}
+	for i, diff := range diffs {
+		if diff.String() != exp[i] {
+			t.Errorf("%d: expected %s, got %s", i, exp[i], diff)
+		}
+	}
+
+	diffs = Diff(b, a)
+	if len(diffs) != 3 || diffs[1].Kind != DiffAdded || diffs[1].A != nil || diffs[1].B.Str() != "horse" {
+		t.Errorf("Unexpected differences %v", diffs)
+	}
+
+	diffs = Diff(NewValue("dog"), NewValue([]interface{}{}))
+	if len(diffs) != 1 || diffs[0].String() != `value: changed "dog" -> []` {
+		t.Errorf("Unexpected differences %v", diffs)
+	}
+}
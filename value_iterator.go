@@ -0,0 +1,72 @@
+package ethutil
+
+// Iterates over the elements of a list value
+//
+//	it := val.NewIterator()
+//	for it.Next() {
+//		fmt.Println(it.Idx(), it.Value())
+//	}
+type ValueIterator struct {
+	val  *Value
+	list []interface{}
+	idx  int
+	cur  *Value
+}
+
+// Creates an iterator over the elements of the list. A value which isn't a
+// list has no elements.
+func (val *Value) NewIterator() *ValueIterator {
+	list, _ := val.Val.([]interface{})
+
+	return &ValueIterator{val: val, list: list, idx: -1}
+}
+
+// Moves to the next element, returns false at the end of the list
+func (it *ValueIterator) Next() bool {
+	if it.idx+1 >= len(it.list) {
+		it.cur = nil
+
+		return false
+	}
+
+	it.idx++
+	it.cur = it.val.child(it.idx, it.list[it.idx])
+
+	return true
+}
+
+// The current element
+func (it *ValueIterator) Value() *Value {
+	return it.cur
+}
+
+// The index of the current element
+func (it *ValueIterator) Idx() int {
+	return it.idx
+}
+
+// Calls fn for the value and, depth first, for everything nested in it. The
+// path holds the indices leading from val to v and is empty for val itself.
+// Walking stops at the first error fn returns, which Walk then returns.
+func (val *Value) Walk(fn func(path []int, v *Value) error) error {
+	return walkValue(nil, val, fn)
+}
+
+func walkValue(path []int, val *Value, fn func(path []int, v *Value) error) error {
+	if err := fn(path, val); err != nil {
+		return err
+	}
+
+	it := val.NewIterator()
+	for it.Next() {
+		elem := make([]int, len(path)+1)
+		copy(elem, path)
+		elem[len(path)] = it.Idx()
+
+		if err := walkValue(elem, it.Value(), fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package ethutil
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestValueIterator(t *testing.T) {
+	val := NewValue([]interface{}{"dog", "cat", uint(3)})
+
+	var items []string
+	for it := val.NewIterator(); it.Next(); {
+		items = append(items, fmt.Sprintf("%d:%v", it.Idx(), it.Value().Raw()))
+	}
+	if fmt.Sprint(items) != "[0:dog 1:cat 2:3]" {
+		t.Errorf("Unexpected items %v", items)
+	}
+
+	if NewValue("dog").NewIterator().Next() {
+		t.Error("Expected no elements for a string")
+	}
+
+	// Elements know where they are
+	it := val.NewIterator()
+	it.Next()
+	it.Next()
+	if _, err := it.Value().TrySlice(); err == nil || err.Error() != "[1]: expected list, got bytes" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestValueWalk(t *testing.T) {
+	val := NewValue([]interface{}{"dog", []interface{}{"cat", []interface{}{}}, "horse"})
+
+	var visited []string
+	err := val.Walk(func(path []int, v *Value) error {
+		visited = append(visited, fmt.Sprintf("%v=%s", path, v.Str()))
+
+		return nil
+	})
+	if exp := "[[]= [0]=dog [1]= [1 0]=cat [1 1]= [2]=horse]"; err != nil || fmt.Sprint(visited) != exp {
+		t.Errorf("Expected %s, got %v (%v)", exp, visited, err)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = val.Walk(func(path []int, v *Value) error {
+		count++
+		if v.Str() == "cat" {
+			return stop
+		}
+
+		return nil
+	})
+	if err != stop || count != 4 {
+		t.Errorf("Expected to stop after 4 values, got %d (%v)", count, err)
+	}
+}
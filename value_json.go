@@ -0,0 +1,165 @@
+package ethutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Renders the value as JSON. The mapping follows the value's RLP encoding:
+// lists become arrays and byte strings (which includes numbers) become 0x
+// prefixed hex strings. With printable set, byte strings holding printable
+// ASCII are rendered as plain strings instead, unless they start with 0x
+// themselves.
+//
+//	["0x636174", ["0x01", "0x0400"]]        // JSON(false)
+//	["cat", ["0x01", "0x0400"]]             // JSON(true)
+//
+// UnmarshalJSON reads either form back such that the value encodes to the
+// same RLP data.
+func (val *Value) JSON(printable bool) ([]byte, error) {
+	enc, err := EncodeToBytes(val.Val)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeJson(&buf, enc, printable); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Implements json.Marshaler, byte strings are always rendered as hex
+func (val *Value) MarshalJSON() ([]byte, error) {
+	return val.JSON(false)
+}
+
+// Implements json.Unmarshaler. Strings starting with 0x are read as hex
+// encoded bytes, other strings as text, numbers as unsigned integers and
+// arrays as lists. The resulting value is what decoding its RLP encoding
+// yields, so val.Encode() reproduces the data JSON was called on. As usual
+// null leaves the value untouched.
+func (val *Value) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var lit interface{}
+	if err := dec.Decode(&lit); err != nil {
+		return err
+	} else if lit == nil {
+		return nil
+	}
+
+	obj, err := fromJson(lit)
+	if err != nil {
+		return err
+	}
+
+	enc, err := EncodeToBytes(obj)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := DecodeValue(enc)
+	if err != nil {
+		return err
+	}
+	val.Val = decoded.Val
+
+	return nil
+}
+
+// Writes the single RLP item in enc as JSON
+func writeJson(buf *bytes.Buffer, enc []byte, printable bool) error {
+	kind, content, _, err := Split(enc)
+	if err != nil {
+		return err
+	}
+
+	if kind != RlpList {
+		if printable && isPrintable(content) {
+			str, _ := json.Marshal(string(content))
+			buf.Write(str)
+		} else {
+			buf.WriteString(`"0x` + hex.EncodeToString(content) + `"`)
+		}
+
+		return nil
+	}
+
+	buf.WriteByte('[')
+	for i := 0; len(content) > 0; i++ {
+		_, _, rest, err := Split(content)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if err := writeJson(buf, content[:len(content)-len(rest)], printable); err != nil {
+			return err
+		}
+		content = rest
+	}
+	buf.WriteByte(']')
+
+	return nil
+}
+
+// Whether b can be rendered as a plain JSON string without becoming ambiguous
+func isPrintable(b []byte) bool {
+	if len(b) == 0 || bytes.HasPrefix(b, []byte("0x")) {
+		return false
+	}
+
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Maps a JSON literal decoded with UseNumber to something Encode accepts
+func fromJson(lit interface{}) (interface{}, error) {
+	switch t := lit.(type) {
+	case string:
+		if strings.HasPrefix(t, "0x") {
+			b, err := hex.DecodeString(t[2:])
+			if err != nil {
+				return nil, fmt.Errorf("json: invalid hex string %q", t)
+			}
+
+			return b, nil
+		}
+
+		return t, nil
+	case json.Number:
+		num, ok := new(big.Int).SetString(string(t), 10)
+		if !ok || num.Sign() < 0 {
+			return nil, fmt.Errorf("json: %s is not an unsigned integer", t)
+		}
+
+		return num, nil
+	case []interface{}:
+		list := make([]interface{}, len(t))
+		for i, v := range t {
+			val, err := fromJson(v)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = val
+		}
+
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("json: can't map %T to a value", lit)
+}
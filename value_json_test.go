@@ -0,0 +1,50 @@
+package ethutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestValueJSON(t *testing.T) {
+	val := NewValue([]interface{}{"cat", []interface{}{1, uint(1024), ""}, "0x12", []byte{0xff}})
+
+	hexJson, err := json.Marshal(val)
+	if exp := `["0x636174",["0x01","0x0400","0x"],"0x30783132","0xff"]`; err != nil || string(hexJson) != exp {
+		t.Errorf("Expected %s, got %s (%v)", exp, hexJson, err)
+	}
+
+	textJson, err := val.JSON(true)
+	if exp := `["cat", ["0x01", "0x0400", "0x"], "0x30783132", "0xff"]`; err != nil || string(textJson) != exp {
+		t.Errorf("Expected %s, got %s (%v)", exp, textJson, err)
+	}
+
+	for _, data := range [][]byte{hexJson, textJson} {
+		out := new(Value)
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Errorf("%s: %v", data, err)
+			continue
+		}
+
+		if !bytes.Equal(out.Encode(), val.Encode()) {
+			t.Errorf("%s: encodes to %x, expected %x", data, out.Encode(), val.Encode())
+		}
+	}
+}
+
+func TestValueUnmarshalJSON(t *testing.T) {
+	var val Value
+	if err := json.Unmarshal([]byte(`["dog", 1024, []]`), &val); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.Get(0).Str() != "dog" || val.Get(1).BigInt().Int64() != 1024 || val.Get(2).Len() != 0 {
+		t.Errorf("Unexpected value %v", val.Val)
+	}
+
+	for _, input := range []string{`-1`, `1.5`, `"0xzz"`, `{"a": 1}`} {
+		if err := json.Unmarshal([]byte(input), &val); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
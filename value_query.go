@@ -0,0 +1,119 @@
+package ethutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrValueQuerySyntax = errors.New("invalid segment")
+
+// QueryError is returned by Query. Segment is the 1 based position of the
+// failing segment in the selector, Err is either ErrValueQuerySyntax or the
+// *ValueError of the failing lookup.
+type QueryError struct {
+	Query   string
+	Segment int
+	Text    string
+	Err     error
+}
+
+func (err *QueryError) Error() string {
+	return fmt.Sprintf("query %q: segment %d %q: %v", err.Query, err.Segment, err.Text, err.Err)
+}
+
+// Follows the indices down in to nested lists, e.g. val.Path(2, 0, 1) is the
+// checked equivalent of val.Get(2).Get(0).Get(1). The error names the path of
+// the first missing element.
+func (val *Value) Path(indices ...int) (*Value, error) {
+	cur := val
+	for _, idx := range indices {
+		next, err := cur.TryGet(idx)
+		if err != nil {
+			return nil, err
+		}
+		cur = next
+	}
+
+	return cur, nil
+}
+
+// Selects the values matching the selector. A selector is a list of indices
+// either separated by dots or in brackets, "2.0.1" and "[2][0][1]" are the
+// same. The wildcard * selects all elements of a list:
+//
+//	val.Query("[2][*][0]") // The first element of every list in val.Get(2)
+//
+// Every lookup must succeed, the *QueryError tells which segment didn't.
+func (val *Value) Query(selector string) ([]*Value, error) {
+	segments, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []*Value{val}
+	for i, seg := range segments {
+		var next []*Value
+		for _, v := range values {
+			if seg == "*" {
+				d, err := v.TrySlice()
+				if err != nil {
+					return nil, &QueryError{selector, i + 1, seg, err}
+				}
+				for idx := range d {
+					next = append(next, v.child(idx, d[idx]))
+				}
+
+				continue
+			}
+
+			idx, _ := strconv.Atoi(seg)
+			child, err := v.TryGet(idx)
+			if err != nil {
+				return nil, &QueryError{selector, i + 1, seg, err}
+			}
+			next = append(next, child)
+		}
+		values = next
+	}
+
+	return values, nil
+}
+
+// Splits a selector in to its segments, each either * or an index
+func parseSelector(selector string) ([]string, error) {
+	var segments []string
+
+	for rest := selector; len(rest) > 0; {
+		var seg string
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, &QueryError{selector, len(segments) + 1, rest, ErrValueQuerySyntax}
+			}
+			seg, rest = rest[1:end], rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			seg, rest = rest[:end], rest[end:]
+		}
+
+		segments = append(segments, seg)
+		if _, err := strconv.ParseUint(seg, 10, 31); err != nil && seg != "*" {
+			return nil, &QueryError{selector, len(segments), seg, ErrValueQuerySyntax}
+		}
+
+		// Dots separate segments but mustn't trail
+		if len(rest) > 0 && rest[0] == '.' {
+			rest = rest[1:]
+			if len(rest) == 0 {
+				return nil, &QueryError{selector, len(segments) + 1, "", ErrValueQuerySyntax}
+			}
+		}
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,93 @@
+package ethutil
+
+import (
+	"testing"
+)
+
+func TestValuePath(t *testing.T) {
+	val := NewValue([]interface{}{"dog", 0, []interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}})
+
+	if v, err := val.Path(2, 0, 1); err != nil || v.Str() != "b" {
+		t.Errorf("Expected b, got %v (%v)", v, err)
+	}
+
+	if v, err := val.Path(); err != nil || v != val {
+		t.Errorf("Expected the value itself, got %v (%v)", v, err)
+	}
+
+	if _, err := val.Path(2, 1, 1); err == nil || err.Error() != "[2][1][1]: index out of range, got list of 1" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestValueQuery(t *testing.T) {
+	val := NewValue([]interface{}{"dog", 0, []interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}})
+
+	tests := []struct {
+		query string
+		exp   []string
+	}{
+		{"", nil},
+		{"2.0.1", []string{"b"}},
+		{"[2][0][1]", []string{"b"}},
+		{"2[1].0", []string{"c"}},
+		{"[2][*][0]", []string{"a", "c"}},
+		{"2.*.*", []string{"a", "b", "c"}},
+	}
+
+	for _, test := range tests {
+		res, err := val.Query(test.query)
+		if err != nil {
+			t.Errorf("%q: %v", test.query, err)
+			continue
+		}
+
+		if test.query == "" {
+			if len(res) != 1 || res[0] != val {
+				t.Errorf("%q: expected the value itself", test.query)
+			}
+			continue
+		}
+
+		var strs []string
+		for _, v := range res {
+			strs = append(strs, v.MustStr())
+		}
+		if len(strs) != len(test.exp) {
+			t.Errorf("%q: expected %q, got %q", test.query, test.exp, strs)
+			continue
+		}
+		for i := range strs {
+			if strs[i] != test.exp[i] {
+				t.Errorf("%q: expected %q, got %q", test.query, test.exp, strs)
+				break
+			}
+		}
+	}
+}
+
+func TestValueQueryErrors(t *testing.T) {
+	val := NewValue([]interface{}{"dog", 0, []interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}})
+
+	tests := []struct {
+		query   string
+		segment int
+		err     string
+	}{
+		{"[2][*][1]", 3, `query "[2][*][1]": segment 3 "1": [2][1][1]: index out of range, got list of 1`},
+		{"0.*", 2, `query "0.*": segment 2 "*": [0]: expected list, got bytes`},
+		{"2.x", 2, `query "2.x": segment 2 "x": invalid segment`},
+		{"2..1", 2, `query "2..1": segment 2 "": invalid segment`},
+		{"2.", 2, `query "2.": segment 2 "": invalid segment`},
+		{"[2", 1, `query "[2": segment 1 "[2": invalid segment`},
+		{"-1", 1, `query "-1": segment 1 "-1": invalid segment`},
+	}
+
+	for _, test := range tests {
+		_, err := val.Query(test.query)
+		qErr, ok := err.(*QueryError)
+		if !ok || qErr.Segment != test.segment || err.Error() != test.err {
+			t.Errorf("%q: expected %s, got %v", test.query, test.err, err)
+		}
+	}
+}
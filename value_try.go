@@ -0,0 +1,244 @@
+package ethutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrValueNotInteger     = errors.New("expected integer")
+	ErrValueOverflow       = errors.New("integer overflow")
+	ErrValueExpectedString = errors.New("expected bytes")
+	ErrValueExpectedList   = errors.New("expected list")
+	ErrValueIndex          = errors.New("index out of range")
+)
+
+// ValueError is returned by the TryX accessors. It records the indices leading
+// from the value the lookup started at to the failing element, e.g.
+//
+//	[2][0]: expected list, got bytes
+type ValueError struct {
+	Err  error
+	Path []int
+	// What the element held instead, empty if that doesn't matter
+	Got string
+}
+
+func (err *ValueError) Error() string {
+	msg := fmt.Sprintf("%s: %v", formatPath(err.Path), err.Err)
+	if err.Got != "" {
+		msg += ", got " + err.Got
+	}
+
+	return msg
+}
+
+// Formats a path as [2][0], the empty path as value
+func formatPath(path []int) string {
+	if len(path) == 0 {
+		return "value"
+	}
+
+	var buf bytes.Buffer
+	for _, idx := range path {
+		fmt.Fprintf(&buf, "[%d]", idx)
+	}
+
+	return buf.String()
+}
+
+// Returns a new value for the idx'th element of val which knows its path.
+// Get doesn't use it to keep lenient lookups cheap.
+func (val *Value) child(idx int, elem interface{}) *Value {
+	path := make([]int, len(val.path)+1)
+	copy(path, val.path)
+	path[len(val.path)] = idx
+
+	v := NewValue(elem)
+	v.path = path
+
+	return v
+}
+
+func (val *Value) errorf(err error) *ValueError {
+	return &ValueError{Err: err, Path: val.path, Got: describeValue(val.Val)}
+}
+
+// Names the kind of data a value holds for error messages
+func describeValue(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case []interface{}:
+		return "list"
+	case []byte, string:
+		return "bytes"
+	case byte:
+		return "byte"
+	case uint, uint16, uint32, uint64, int, int8, int16, int32, int64, *big.Int, big.Int:
+		return "integer"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+// Like Str but returns an error if the value isn't a string
+func (val *Value) TryStr() (string, error) {
+	switch val.Val.(type) {
+	case []byte, string, byte:
+		return val.Str(), nil
+	}
+
+	return "", val.errorf(ErrValueExpectedString)
+}
+
+// Like Bytes but returns an error if the value isn't a string
+func (val *Value) TryBytes() ([]byte, error) {
+	switch val.Val.(type) {
+	case []byte, string, byte:
+		return val.Bytes(), nil
+	}
+
+	return nil, val.errorf(ErrValueExpectedString)
+}
+
+// Like Uint but returns an error if the value isn't an integer or doesn't fit
+// in a uint64
+func (val *Value) TryUint() (uint64, error) {
+	num, err := val.bigInt()
+	if err != nil {
+		return 0, err
+	} else if num.Sign() < 0 || num.BitLen() > 64 {
+		return 0, &ValueError{Err: ErrValueOverflow, Path: val.path}
+	}
+
+	return num.Uint64(), nil
+}
+
+// Like Int but returns an error if the value isn't an integer or doesn't fit
+// in an int64
+func (val *Value) TryInt() (int64, error) {
+	num, err := val.bigInt()
+	if err != nil {
+		return 0, err
+	} else if num.BitLen() > 63 {
+		return 0, &ValueError{Err: ErrValueOverflow, Path: val.path}
+	}
+
+	return num.Int64(), nil
+}
+
+// Like Byte but returns an error if the value isn't an integer or doesn't fit
+// in a byte
+func (val *Value) TryByte() (byte, error) {
+	num, err := val.TryUint()
+	if err != nil {
+		return 0, err
+	} else if num > 0xff {
+		return 0, &ValueError{Err: ErrValueOverflow, Path: val.path}
+	}
+
+	return byte(num), nil
+}
+
+// Like BigInt but returns an error if the value isn't an integer
+func (val *Value) TryBigInt() (*big.Int, error) {
+	return val.bigInt()
+}
+
+// Like Slice but returns an error if the value isn't a list
+func (val *Value) TrySlice() ([]interface{}, error) {
+	if d, ok := val.Val.([]interface{}); ok {
+		return d, nil
+	}
+
+	return nil, val.errorf(ErrValueExpectedList)
+}
+
+// Like Get but returns an error if the value isn't a list or has no idx'th
+// element
+func (val *Value) TryGet(idx int) (*Value, error) {
+	d, err := val.listIndex(idx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return val.child(idx, d[idx]), nil
+}
+
+// The MustX accessors are like their TryX counterparts but panic with the
+// *ValueError instead of returning it
+
+func (val *Value) MustStr() string {
+	str, err := val.TryStr()
+	if err != nil {
+		panic(err)
+	}
+
+	return str
+}
+
+func (val *Value) MustBytes() []byte {
+	b, err := val.TryBytes()
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+func (val *Value) MustUint() uint64 {
+	num, err := val.TryUint()
+	if err != nil {
+		panic(err)
+	}
+
+	return num
+}
+
+func (val *Value) MustInt() int64 {
+	num, err := val.TryInt()
+	if err != nil {
+		panic(err)
+	}
+
+	return num
+}
+
+func (val *Value) MustByte() byte {
+	b, err := val.TryByte()
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+func (val *Value) MustBigInt() *big.Int {
+	num, err := val.TryBigInt()
+	if err != nil {
+		panic(err)
+	}
+
+	return num
+}
+
+func (val *Value) MustSlice() []interface{} {
+	d, err := val.TrySlice()
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func (val *Value) MustGet(idx int) *Value {
+	v, err := val.TryGet(idx)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
@@ -0,0 +1,65 @@
+package ethutil
+
+import (
+	"testing"
+)
+
+// Returns the sentinel wrapped in a *ValueError
+func valueErr(err error) error {
+	if valErr, ok := err.(*ValueError); ok {
+		return valErr.Err
+	}
+
+	return err
+}
+
+func TestValueTryAccessors(t *testing.T) {
+	val := NewValueFromBytes(Encode([]interface{}{"dog", 0, []interface{}{"cat", []interface{}{}}}))
+
+	if str, err := val.Get(0).TryStr(); err != nil || str != "dog" {
+		t.Errorf("Expected dog, got %q (%v)", str, err)
+	}
+
+	// A zero field is fine, a missing one isn't
+	if num, err := val.Get(1).TryUint(); err != nil || num != 0 {
+		t.Errorf("Expected 0, got %d (%v)", num, err)
+	}
+	if _, err := val.Get(4).TryUint(); valueErr(err) != ErrValueNotInteger {
+		t.Errorf("Expected %v, got %v", ErrValueNotInteger, err)
+	}
+
+	tests := []struct {
+		fn  func() error
+		err string
+	}{
+		{func() error { _, err := val.MustGet(2).MustGet(0).TrySlice(); return err }, "[2][0]: expected list, got bytes"},
+		{func() error { _, err := val.MustGet(2).MustGet(1).TryBytes(); return err }, "[2][1]: expected bytes, got list"},
+		{func() error { _, err := val.MustGet(2).TryGet(5); return err }, "[2][5]: index out of range, got list of 2"},
+		{func() error { _, err := val.TryGet(-1); return err }, "[-1]: index out of range, got list of 3"},
+		{func() error { _, err := val.TryStr(); return err }, "value: expected bytes, got list"},
+		// Get doesn't track the path
+		{func() error { _, err := val.Get(2).Get(7).TryBigInt(); return err }, "value: expected integer, got nil"},
+	}
+
+	for i, test := range tests {
+		if err := test.fn(); err == nil || err.Error() != test.err {
+			t.Errorf("%d: expected %q, got %v", i, test.err, err)
+		}
+	}
+}
+
+func TestValueMustAccessors(t *testing.T) {
+	val := NewValue([]interface{}{"dog", []interface{}{uint(3)}})
+
+	if val.MustGet(1).MustGet(0).MustUint() != 3 || val.MustGet(0).MustStr() != "dog" {
+		t.Error("Unexpected values")
+	}
+
+	defer func() {
+		err, _ := recover().(error)
+		if valueErr(err) != ErrValueExpectedString {
+			t.Errorf("Expected a panic with %v, got %v", ErrValueExpectedString, err)
+		}
+	}()
+	val.MustGet(1).MustBytes()
+}